@@ -0,0 +1,372 @@
+// Package anki reads decks that have been exported from Anki as .apkg files so that they can be imported as cards.
+package anki
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The possible answers given to a card when it's reviewed in Anki.
+const (
+	EaseAgain = 1
+	EaseHard  = 2
+	EaseGood  = 3
+	EaseEasy  = 4
+)
+
+// reviewTypeManual is the type of review log entry created when a card is rescheduled by hand rather than answered.
+const reviewTypeManual = 4
+
+// Package is an opened .apkg file.
+type Package struct {
+	Notes []*Note
+
+	zip   *zip.ReadCloser
+	media map[string]*zip.File
+}
+
+// Note is a single note from an Anki deck. A note can produce several Anki cards, such as a forward and a reverse
+// card, but a note is what becomes a single card when imported.
+type Note struct {
+	ID      int64
+	Created time.Time
+
+	// Deck is the full name of the deck the note is in, such as "Maths::Algebra". It's empty if the deck is unknown.
+	Deck string
+
+	// Fields are the note's fields in order, normally the front and then the back. They contain HTML.
+	Fields []string
+	Tags   []string
+
+	// Reviews are the reviews of the note's first card, oldest first.
+	Reviews []Review
+}
+
+// Review is a single review of a card.
+type Review struct {
+	Date     time.Time
+	Duration time.Duration
+
+	// Ease is the answer given, one of EaseAgain, EaseHard, EaseGood or EaseEasy.
+	Ease int
+}
+
+// imageRegex matches the image tags Anki uses to include media in fields.
+var imageRegex = regexp.MustCompile(`(?i)<img[^>]*\ssrc\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+
+// Images returns the names of the media files included as images in a field, in the order they appear.
+func Images(field string) []string {
+	images := []string{}
+
+	for _, match := range imageRegex.FindAllStringSubmatch(field, -1) {
+		images = append(images, html.UnescapeString(match[1]+match[2]+match[3]))
+	}
+
+	return images
+}
+
+// Open reads the notes in a .apkg file.
+// Only packages exported with "Support older Anki versions" ticked can be read, since newer packages compress the
+// collection in a format that isn't supported.
+func Open(path string) (*Package, error) {
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't open package %q: %w", path, err)
+	}
+
+	pkg, err := readPackage(reader)
+	if err != nil {
+		reader.Close()
+		return nil, fmt.Errorf("couldn't read package %q: %w", path, err)
+	}
+
+	return pkg, nil
+}
+
+// Close closes the underlying .apkg file. Media can't be extracted once the package has been closed.
+func (pkg *Package) Close() error {
+	return pkg.zip.Close()
+}
+
+// ExtractMedia copies the media file with the name given, as returned by Images, into a directory and returns the
+// path to the copy.
+func (pkg *Package) ExtractMedia(name string, dir string) (string, error) {
+	file, ok := pkg.media[name]
+	if !ok {
+		return "", fmt.Errorf("media %q isn't in the package", name)
+	}
+
+	in, err := file.Open()
+	if err != nil {
+		return "", fmt.Errorf("couldn't open media %q: %w", name, err)
+	}
+	defer in.Close()
+
+	// Media names come from the package, so only the base name is used to make sure it can't be written elsewhere.
+	outPath := filepath.Join(dir, filepath.Base(name))
+
+	out, err := os.Create(outPath)
+	if err != nil {
+		return "", fmt.Errorf("couldn't create file for media %q: %w", name, err)
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, in)
+	if err != nil {
+		return "", fmt.Errorf("couldn't extract media %q: %w", name, err)
+	}
+
+	return outPath, nil
+}
+
+// readPackage reads the collection and media index from an opened .apkg file.
+func readPackage(reader *zip.ReadCloser) (*Package, error) {
+	files := map[string]*zip.File{}
+	for _, file := range reader.File {
+		files[file.Name] = file
+	}
+
+	// Packages exported for older versions contain "collection.anki21", or "collection.anki2" if they're very old.
+	// Newer packages also contain a "collection.anki2" but it's just a placeholder telling you to update Anki, so
+	// "collection.anki21" is checked first.
+	collectionFile := files["collection.anki21"]
+	if collectionFile == nil {
+		collectionFile = files["collection.anki2"]
+	}
+
+	if collectionFile == nil {
+		if files["collection.anki21b"] != nil {
+			return nil, fmt.Errorf("the package uses a newer format that isn't supported, please export it again with 'Support older Anki versions' ticked")
+		}
+
+		return nil, fmt.Errorf("the package doesn't contain a collection")
+	}
+
+	collection, err := readZipFile(collectionFile)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := openSQLite(collection)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't open collection: %w", err)
+	}
+
+	notes, err := readNotes(db)
+	if err != nil {
+		return nil, err
+	}
+
+	media := map[string]*zip.File{}
+
+	if mediaFile := files["media"]; mediaFile != nil {
+		mediaBytes, err := readZipFile(mediaFile)
+		if err != nil {
+			return nil, err
+		}
+
+		// The media index maps the names of files in the package, which are just numbers, to their original names.
+		mediaNames := map[string]string{}
+		err = json.Unmarshal(mediaBytes, &mediaNames)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't parse media index: %w", err)
+		}
+
+		for zipName, name := range mediaNames {
+			if file, ok := files[zipName]; ok {
+				media[name] = file
+			}
+		}
+	}
+
+	return &Package{
+		Notes: notes,
+		zip:   reader,
+		media: media,
+	}, nil
+}
+
+// readNotes reads all the notes from a collection, along with their decks and reviews.
+func readNotes(db *sqliteDB) ([]*Note, error) {
+	decks, err := readDecks(db)
+	if err != nil {
+		return nil, err
+	}
+
+	noteRows, err := db.table("notes")
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read notes: %w", err)
+	}
+
+	cardRows, err := db.table("cards")
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read cards: %w", err)
+	}
+
+	reviewRows, err := db.table("revlog")
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read review log: %w", err)
+	}
+
+	notes := []*Note{}
+	notesByID := map[int64]*Note{}
+
+	// notes has the columns id, guid, mid, mod, usn, tags, flds, sfld, csum, flags and data.
+	for _, row := range noteRows {
+		note := &Note{
+			ID:      row.rowid,
+			Created: time.Unix(0, row.rowid*int64(time.Millisecond)),
+			Fields:  strings.Split(sqliteString(row.values, 6), "\x1f"),
+			Tags:    strings.Fields(sqliteString(row.values, 5)),
+		}
+
+		notes = append(notes, note)
+		notesByID[note.ID] = note
+	}
+
+	// cards has the columns id, nid, did, ord and more that aren't needed. Only the first card of each note is used,
+	// since any others ask the question a different way, such as a reverse card showing the back first.
+	notesByCard := map[int64]*Note{}
+
+	for _, row := range cardRows {
+		note := notesByID[sqliteInt64(row.values, 1)]
+		if note == nil || sqliteInt64(row.values, 3) != 0 {
+			continue
+		}
+
+		note.Deck = decks[sqliteInt64(row.values, 2)]
+		notesByCard[row.rowid] = note
+	}
+
+	// revlog has the columns id, cid, usn, ease, ivl, lastIvl, factor, time and type.
+	for _, row := range reviewRows {
+		note := notesByCard[sqliteInt64(row.values, 1)]
+		ease := int(sqliteInt64(row.values, 3))
+
+		if note == nil || ease < EaseAgain || ease > EaseEasy || sqliteInt64(row.values, 8) == reviewTypeManual {
+			continue
+		}
+
+		note.Reviews = append(note.Reviews, Review{
+			Date:     time.Unix(0, row.rowid*int64(time.Millisecond)),
+			Duration: time.Duration(sqliteInt64(row.values, 7)) * time.Millisecond,
+			Ease:     ease,
+		})
+	}
+
+	for _, note := range notes {
+		sort.Slice(note.Reviews, func(i, j int) bool {
+			return note.Reviews[i].Date.Before(note.Reviews[j].Date)
+		})
+	}
+
+	return notes, nil
+}
+
+// readDecks returns the names of all the decks in a collection, mapped by ID.
+func readDecks(db *sqliteDB) (map[int64]string, error) {
+	decks := map[int64]string{}
+
+	// Newer collections keep decks in their own table, with the parts of the name separated by \x1f.
+	hasDecksTable, err := db.hasTable("decks")
+	if err != nil {
+		return nil, err
+	}
+
+	if hasDecksTable {
+		rows, err := db.table("decks")
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read decks: %w", err)
+		}
+
+		for _, row := range rows {
+			decks[row.rowid] = strings.ReplaceAll(sqliteString(row.values, 1), "\x1f", "::")
+		}
+
+		return decks, nil
+	}
+
+	// Older collections keep them as JSON in the 11th column of the single row in the col table.
+	rows, err := db.table("col")
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read collection info: %w", err)
+	}
+
+	if len(rows) == 0 || sqliteString(rows[0].values, 10) == "" {
+		return decks, nil
+	}
+
+	rawDecks := map[string]struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	}{}
+
+	err = json.Unmarshal([]byte(sqliteString(rows[0].values, 10)), &rawDecks)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't parse decks: %w", err)
+	}
+
+	for _, deck := range rawDecks {
+		decks[deck.ID] = deck.Name
+	}
+
+	return decks, nil
+}
+
+// readZipFile reads the whole of a file in a zip archive.
+func readZipFile(file *zip.File) ([]byte, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("couldn't open %q: %w", file.Name, err)
+	}
+	defer reader.Close()
+
+	contents, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read %q: %w", file.Name, err)
+	}
+
+	return contents, nil
+}
+
+// sqliteString returns the value in a row at index i as a string, or the empty string if it isn't text.
+func sqliteString(values []interface{}, i int) string {
+	if i >= len(values) {
+		return ""
+	}
+
+	switch value := values[i].(type) {
+	case string:
+		return value
+	case []byte:
+		return string(value)
+	default:
+		return ""
+	}
+}
+
+// sqliteInt64 returns the value in a row at index i as an integer, or 0 if it isn't one.
+func sqliteInt64(values []interface{}, i int) int64 {
+	if i >= len(values) {
+		return 0
+	}
+
+	switch value := values[i].(type) {
+	case int64:
+		return value
+	case float64:
+		return int64(value)
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,55 @@
+package anki
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestOpen tests reading notes, decks, reviews and media from a package. The package in testdata uses small pages so
+// that the notes span several pages and one of them overflows onto extra pages.
+func TestOpen(t *testing.T) {
+	pkg, err := Open("testdata/example.apkg")
+	if !assert.NoError(t, err, "expected no error opening package") {
+		return
+	}
+	defer pkg.Close()
+
+	assert.Len(t, pkg.Notes, 40, "expected every note to be read")
+
+	first := pkg.Notes[0]
+	assert.Equal(t, time.Date(2021, 02, 16, 10, 18, 0, 0, time.UTC), first.Created.UTC(), "expected creation date from note ID")
+	assert.Equal(t, "Maths::Complex Numbers", first.Deck, "expected deck name")
+	assert.Equal(t, []string{"hard", "exam"}, first.Tags, "expected tags")
+	assert.Equal(t, []string{"q0.png"}, Images(first.Fields[0]), "expected question image")
+	assert.Equal(t, []string{"a0.png"}, Images(first.Fields[1]), "expected answer image")
+
+	// The manual reschedule and the review of the reverse card should be left out.
+	if assert.Len(t, first.Reviews, 2, "expected only reviews of the first card") {
+		assert.Equal(t, EaseAgain, first.Reviews[0].Ease)
+		assert.Equal(t, 12*time.Second, first.Reviews[0].Duration)
+		assert.Equal(t, EaseGood, first.Reviews[1].Ease)
+	}
+
+	assert.True(t, strings.HasSuffix(pkg.Notes[1].Fields[1], "explanation. </p>"), "expected overflowing field to be read in full")
+	assert.Equal(t, "Default", pkg.Notes[39].Deck, "expected deck name")
+
+	dir, err := ioutil.TempDir("", "sergeant-anki-")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	path, err := pkg.ExtractMedia("q0.png", dir)
+	if assert.NoError(t, err, "expected no error extracting media") {
+		contents, _ := ioutil.ReadFile(path)
+		assert.Equal(t, "\x89PNG question", string(contents), "expected media contents")
+	}
+
+	_, err = pkg.ExtractMedia("q1.png", dir)
+	assert.Error(t, err, "expected error extracting media that isn't in the package")
+}
@@ -0,0 +1,391 @@
+package anki
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// sqliteDB is a minimal, read-only reader for SQLite database files.
+// Anki stores collections as SQLite databases, but all that's needed to import them is to read every row of a few
+// tables, so rather than depending on a full SQLite implementation this walks the table b-trees directly. It doesn't
+// understand indexes, WITHOUT ROWID tables or write-ahead logs, none of which appear in exported Anki packages.
+//
+// The file format is documented at https://www.sqlite.org/fileformat.html.
+type sqliteDB struct {
+	data []byte
+
+	pageSize   int
+	usableSize int
+}
+
+// sqliteRow is a single row of a table. Values are nil, int64, float64, string or []byte.
+type sqliteRow struct {
+	rowid  int64
+	values []interface{}
+}
+
+// sqliteMaxDepth is the deepest a table b-tree is allowed to be before the file is assumed to be malformed.
+const sqliteMaxDepth = 32
+
+// openSQLite returns a reader for the SQLite database contained in data.
+func openSQLite(data []byte) (*sqliteDB, error) {
+	if len(data) < 100 || string(data[:16]) != "SQLite format 3\x00" {
+		return nil, fmt.Errorf("not a SQLite database")
+	}
+
+	pageSize := int(binary.BigEndian.Uint16(data[16:18]))
+	if pageSize == 1 {
+		pageSize = 65536
+	}
+
+	if pageSize < 512 || pageSize&(pageSize-1) != 0 {
+		return nil, fmt.Errorf("invalid SQLite page size %d", pageSize)
+	}
+
+	if encoding := binary.BigEndian.Uint32(data[56:60]); encoding != 0 && encoding != 1 {
+		return nil, fmt.Errorf("only UTF-8 SQLite databases are supported")
+	}
+
+	return &sqliteDB{
+		data:       data,
+		pageSize:   pageSize,
+		usableSize: pageSize - int(data[20]),
+	}, nil
+}
+
+// hasTable reports whether a table with the given name exists.
+func (db *sqliteDB) hasTable(name string) (bool, error) {
+	root, err := db.rootPage(name)
+	if err != nil {
+		return false, err
+	}
+
+	return root != 0, nil
+}
+
+// table returns every row in the table with the given name.
+func (db *sqliteDB) table(name string) ([]sqliteRow, error) {
+	root, err := db.rootPage(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if root == 0 {
+		return nil, fmt.Errorf("table %q doesn't exist", name)
+	}
+
+	return db.rows(root)
+}
+
+// rootPage looks up the root page of a table in the schema table, returning 0 if there's no table with that name.
+func (db *sqliteDB) rootPage(name string) (int, error) {
+	schema, err := db.rows(1)
+	if err != nil {
+		return 0, fmt.Errorf("couldn't read schema: %w", err)
+	}
+
+	// The schema table has the columns type, name, tbl_name, rootpage and sql.
+	for _, row := range schema {
+		if len(row.values) < 4 {
+			continue
+		}
+
+		if row.values[0] == "table" && row.values[1] == name {
+			root, ok := row.values[3].(int64)
+			if !ok || root < 1 {
+				return 0, fmt.Errorf("invalid root page for table %q", name)
+			}
+
+			return int(root), nil
+		}
+	}
+
+	return 0, nil
+}
+
+// rows returns every row in the table b-tree starting at the root page given.
+func (db *sqliteDB) rows(root int) ([]sqliteRow, error) {
+	rows := []sqliteRow{}
+
+	err := db.walk(root, 0, map[int]bool{}, func(rowid int64, payload []byte) error {
+		values, err := parseSQLiteRecord(payload)
+		if err != nil {
+			return fmt.Errorf("row %d: %w", rowid, err)
+		}
+
+		rows = append(rows, sqliteRow{rowid: rowid, values: values})
+		return nil
+	})
+
+	return rows, err
+}
+
+// page returns the contents of the page with the given number. Pages are numbered from 1.
+func (db *sqliteDB) page(number int) ([]byte, error) {
+	if number < 1 || number*db.pageSize > len(db.data) {
+		return nil, fmt.Errorf("page %d is out of range", number)
+	}
+
+	return db.data[(number-1)*db.pageSize : number*db.pageSize], nil
+}
+
+// walk calls fn with the rowid and payload of every cell in the table b-tree rooted at the page given, in rowid order.
+// Every page walked is added to visited. A page can only appear once in a b-tree, so coming across one again means the
+// file is malformed, and carrying on could take forever.
+func (db *sqliteDB) walk(number int, depth int, visited map[int]bool, fn func(rowid int64, payload []byte) error) error {
+	if depth > sqliteMaxDepth {
+		return fmt.Errorf("table b-tree is too deep")
+	}
+
+	if visited[number] {
+		return fmt.Errorf("page %d appears more than once in a table b-tree", number)
+	}
+	visited[number] = true
+
+	page, err := db.page(number)
+	if err != nil {
+		return err
+	}
+
+	// The first page also contains the 100 byte database header.
+	header := 0
+	if number == 1 {
+		header = 100
+	}
+
+	pageType := page[header]
+	cellCount := int(binary.BigEndian.Uint16(page[header+3:]))
+
+	switch pageType {
+	case 0x0d: // Table leaf page.
+		pointers := header + 8
+
+		for i := 0; i < cellCount; i++ {
+			offset, err := cellOffset(page, pointers, i)
+			if err != nil {
+				return err
+			}
+
+			payloadSize, n := sqliteVarint(page[offset:])
+			if n == 0 {
+				return fmt.Errorf("invalid cell on page %d", number)
+			}
+			offset += n
+
+			// A payload can't be bigger than the whole database, so anything else means the file is corrupt.
+			if payloadSize < 0 || payloadSize > int64(len(db.data)) {
+				return fmt.Errorf("invalid payload size %d on page %d", payloadSize, number)
+			}
+
+			rowid, n := sqliteVarint(page[offset:])
+			if n == 0 {
+				return fmt.Errorf("invalid cell on page %d", number)
+			}
+			offset += n
+
+			payload, err := db.payload(page, offset, int(payloadSize))
+			if err != nil {
+				return fmt.Errorf("couldn't read cell on page %d: %w", number, err)
+			}
+
+			err = fn(rowid, payload)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+
+	case 0x05: // Table interior page.
+		pointers := header + 12
+
+		for i := 0; i < cellCount; i++ {
+			offset, err := cellOffset(page, pointers, i)
+			if err != nil {
+				return err
+			}
+
+			if offset+4 > len(page) {
+				return fmt.Errorf("invalid cell on page %d", number)
+			}
+
+			err = db.walk(int(binary.BigEndian.Uint32(page[offset:])), depth+1, visited, fn)
+			if err != nil {
+				return err
+			}
+		}
+
+		return db.walk(int(binary.BigEndian.Uint32(page[header+8:])), depth+1, visited, fn)
+
+	default:
+		return fmt.Errorf("page %d isn't part of a table (type 0x%02x)", number, pageType)
+	}
+}
+
+// cellOffset returns the offset of the i-th cell in a page from the cell pointer array starting at pointers.
+func cellOffset(page []byte, pointers int, i int) (int, error) {
+	if pointers+2*i+2 > len(page) {
+		return 0, fmt.Errorf("cell pointer %d is out of range", i)
+	}
+
+	offset := int(binary.BigEndian.Uint16(page[pointers+2*i:]))
+	if offset >= len(page) {
+		return 0, fmt.Errorf("cell %d is out of range", i)
+	}
+
+	return offset, nil
+}
+
+// payload reads a cell payload of the size given that starts at offset in page, following overflow pages if it
+// doesn't fit on the page.
+func (db *sqliteDB) payload(page []byte, offset int, size int) ([]byte, error) {
+	maxLocal := db.usableSize - 35
+
+	if size <= maxLocal {
+		if offset+size > len(page) {
+			return nil, fmt.Errorf("payload is out of range")
+		}
+
+		return page[offset : offset+size], nil
+	}
+
+	// These formulas for how much of the payload is kept on the page come straight from the file format documentation.
+	minLocal := (db.usableSize-12)*32/255 - 23
+	local := minLocal + (size-minLocal)%(db.usableSize-4)
+	if local > maxLocal {
+		local = minLocal
+	}
+
+	if offset+local+4 > len(page) {
+		return nil, fmt.Errorf("payload is out of range")
+	}
+
+	payload := make([]byte, 0, size)
+	payload = append(payload, page[offset:offset+local]...)
+	next := int(binary.BigEndian.Uint32(page[offset+local:]))
+
+	for pages := 0; len(payload) < size; pages++ {
+		if next == 0 || pages > len(db.data)/db.pageSize {
+			return nil, fmt.Errorf("overflow pages end before the payload does")
+		}
+
+		overflow, err := db.page(next)
+		if err != nil {
+			return nil, err
+		}
+
+		next = int(binary.BigEndian.Uint32(overflow))
+
+		chunk := db.usableSize - 4
+		if remaining := size - len(payload); remaining < chunk {
+			chunk = remaining
+		}
+
+		payload = append(payload, overflow[4:4+chunk]...)
+	}
+
+	return payload, nil
+}
+
+// parseSQLiteRecord decodes a record, which is how a row's values are stored.
+func parseSQLiteRecord(record []byte) ([]interface{}, error) {
+	headerSize, n := sqliteVarint(record)
+	if n == 0 || int(headerSize) > len(record) || int(headerSize) < n {
+		return nil, fmt.Errorf("invalid record header")
+	}
+
+	header := record[n:headerSize]
+	body := record[headerSize:]
+	values := []interface{}{}
+
+	for len(header) > 0 {
+		serialType, n := sqliteVarint(header)
+		if n == 0 {
+			return nil, fmt.Errorf("invalid record header")
+		}
+		header = header[n:]
+
+		size := sqliteSerialTypeSize(serialType)
+		if size > len(body) {
+			return nil, fmt.Errorf("record is shorter than its header says")
+		}
+
+		content := body[:size]
+		body = body[size:]
+
+		switch {
+		case serialType == 0:
+			values = append(values, nil)
+		case serialType >= 1 && serialType <= 6:
+			values = append(values, sqliteInt(content))
+		case serialType == 7:
+			values = append(values, math.Float64frombits(binary.BigEndian.Uint64(content)))
+		case serialType == 8:
+			values = append(values, int64(0))
+		case serialType == 9:
+			values = append(values, int64(1))
+		case serialType >= 12 && serialType%2 == 0:
+			values = append(values, append([]byte(nil), content...))
+		case serialType >= 13:
+			values = append(values, string(content))
+		default:
+			return nil, fmt.Errorf("invalid serial type %d", serialType)
+		}
+	}
+
+	return values, nil
+}
+
+// sqliteSerialTypeSize returns how many bytes a value with the given serial type takes up in the body of a record.
+func sqliteSerialTypeSize(serialType int64) int {
+	switch {
+	case serialType >= 1 && serialType <= 4:
+		return int(serialType)
+	case serialType == 5:
+		return 6
+	case serialType == 6 || serialType == 7:
+		return 8
+	case serialType >= 12:
+		return int(serialType-12) / 2
+	default:
+		return 0
+	}
+}
+
+// sqliteInt decodes a big-endian two's complement integer of 1 to 8 bytes.
+func sqliteInt(content []byte) int64 {
+	var value int64
+	if len(content) > 0 && content[0]&0x80 != 0 {
+		value = -1
+	}
+
+	for _, b := range content {
+		value = value<<8 | int64(b)
+	}
+
+	return value
+}
+
+// sqliteVarint decodes a SQLite variable-length integer, returning the value and the number of bytes read.
+// If the buffer is too short, the number of bytes read is 0.
+func sqliteVarint(buf []byte) (int64, int) {
+	var value uint64
+
+	for i := 0; i < 8; i++ {
+		if i >= len(buf) {
+			return 0, 0
+		}
+
+		value = value<<7 | uint64(buf[i]&0x7f)
+		if buf[i]&0x80 == 0 {
+			return int64(value), i + 1
+		}
+	}
+
+	if len(buf) < 9 {
+		return 0, 0
+	}
+
+	return int64(value<<8 | uint64(buf[8])), 9
+}
@@ -0,0 +1,62 @@
+package anki
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWalkInvalidPayloadSize tests that cells claiming a negative or impossibly large payload are reported as errors
+// rather than crashing the import.
+func TestWalkInvalidPayloadSize(t *testing.T) {
+	sizes := map[string][]byte{
+		"negative": {0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		"too big":  {0x8f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f},
+	}
+
+	for name, size := range sizes {
+		db := &sqliteDB{data: make([]byte, 1024), pageSize: 512, usableSize: 512}
+
+		// Page 2 is a table leaf page with a single cell at offset 20.
+		page := db.data[512:]
+		page[0] = 0x0d
+		binary.BigEndian.PutUint16(page[3:], 1)
+		binary.BigEndian.PutUint16(page[8:], 20)
+		n := copy(page[20:], size)
+		page[20+n] = 1
+
+		err := db.walk(2, 0, map[int]bool{}, func(rowid int64, payload []byte) error {
+			return nil
+		})
+
+		assert.Error(t, err, "expected error for %s payload size", name)
+	}
+}
+
+// TestWalkRevisitedPage tests that interior pages pointing at a page that has already been walked are reported as
+// errors, rather than the same pages being walked over and over.
+func TestWalkRevisitedPage(t *testing.T) {
+	db := &sqliteDB{data: make([]byte, 1536), pageSize: 512, usableSize: 512}
+
+	// Page 2 is a table interior page with a single cell pointing at page 3, and its right-most pointer is page 3 too.
+	interior := db.data[512:1024]
+	interior[0] = 0x05
+	binary.BigEndian.PutUint16(interior[3:], 1)
+	binary.BigEndian.PutUint32(interior[8:], 3)
+	binary.BigEndian.PutUint16(interior[12:], 20)
+	binary.BigEndian.PutUint32(interior[20:], 3)
+	interior[24] = 1
+
+	// Page 3 is an empty table leaf page.
+	db.data[1024] = 0x0d
+
+	calls := 0
+	err := db.walk(2, 0, map[int]bool{}, func(rowid int64, payload []byte) error {
+		calls++
+		return nil
+	})
+
+	assert.EqualError(t, err, "page 3 appears more than once in a table b-tree")
+	assert.Equal(t, 0, calls)
+}
@@ -0,0 +1,146 @@
+package sergeant
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/albatross-org/go-albatross/entries"
+)
+
+// mediaTypes are the media types of the attachments that can be used as the images of a card, by extension.
+var mediaTypes = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".gif":  "image/gif",
+	".svg":  "image/svg+xml",
+	".webp": "image/webp",
+	".pdf":  "application/pdf",
+}
+
+// IsMediaFile reports whether the file at the path given has an extension that can be used as the image of a card.
+func IsMediaFile(path string) bool {
+	return MediaType(path) != ""
+}
+
+// MediaType returns the media type of the image of a card at the path given, like "image/png" or "application/pdf",
+// based on its extension. It returns a blank string if it can't be used as the image of a card.
+func MediaType(path string) string {
+	return mediaTypes[strings.ToLower(filepath.Ext(path))]
+}
+
+// AttachmentName returns the name that a page of a card's question, answer or alternative solution is attached with.
+// Kind is "question", "answer" or "alternative-<n>", page counts from 1 and pages is how many pages there are in total.
+// A single page is attached without a number, like "question.png", and otherwise pages are numbered, like
+// "answer-2.png".
+func AttachmentName(kind string, page int, pages int, ext string) string {
+	if pages == 1 {
+		return kind + ext
+	}
+
+	return fmt.Sprintf("%s-%d%s", kind, page, ext)
+}
+
+// AlternativeKind returns the kind used in AttachmentName for the nth alternative solution of a card, counting from 1.
+func AlternativeKind(n int) string {
+	return fmt.Sprintf("alternative-%d", n)
+}
+
+// cardPage is a single attachment of a card, identified by its name.
+type cardPage struct {
+	// alternative is the number of the alternative solution this is a page of, or 0 if it's a page of the question
+	// or answer.
+	alternative int
+	page        int
+	path        string
+}
+
+// parseAttachmentName works out which part of a card an attachment is. Kind is "question", "answer" or "alternative"
+// and ok is false if the attachment isn't one of a card's images. Pages without a number, like "question.png", are
+// page 0 so they come before any numbered pages.
+func parseAttachmentName(name string) (kind string, alternative int, page int, ok bool) {
+	if !IsMediaFile(name) {
+		return "", 0, 0, false
+	}
+
+	parts := strings.Split(strings.TrimSuffix(name, filepath.Ext(name)), "-")
+	numbers := []int{}
+
+	for _, part := range parts[1:] {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 1 {
+			return "", 0, 0, false
+		}
+
+		numbers = append(numbers, n)
+	}
+
+	switch {
+	case (parts[0] == "question" || parts[0] == "answer") && len(numbers) == 0:
+		return parts[0], 0, 0, true
+	case (parts[0] == "question" || parts[0] == "answer") && len(numbers) == 1:
+		return parts[0], 0, numbers[0], true
+	case parts[0] == "alternative" && len(numbers) == 1:
+		return parts[0], numbers[0], 0, true
+	case parts[0] == "alternative" && len(numbers) == 2:
+		return parts[0], numbers[0], numbers[1], true
+	}
+
+	return "", 0, 0, false
+}
+
+// cardPagesFromAttachments sorts the attachments of a card entry into the pages of the question, the answer and each
+// alternative solution, in order. Attachments that aren't images of the card are ignored.
+func cardPagesFromAttachments(attachments []entries.Attachment) (questions []string, answers []string, alternatives [][]string) {
+	questionPages, answerPages, alternativePages := []cardPage{}, []cardPage{}, []cardPage{}
+
+	for _, attachment := range attachments {
+		kind, alternative, page, ok := parseAttachmentName(attachment.Name)
+		if !ok {
+			continue
+		}
+
+		switch kind {
+		case "question":
+			questionPages = append(questionPages, cardPage{page: page, path: attachment.AbsPath})
+		case "answer":
+			answerPages = append(answerPages, cardPage{page: page, path: attachment.AbsPath})
+		case "alternative":
+			alternativePages = append(alternativePages, cardPage{alternative: alternative, page: page, path: attachment.AbsPath})
+		}
+	}
+
+	for _, pages := range [][]cardPage{questionPages, answerPages, alternativePages} {
+		sort.SliceStable(pages, func(i, j int) bool {
+			if pages[i].alternative != pages[j].alternative {
+				return pages[i].alternative < pages[j].alternative
+			}
+
+			return pages[i].page < pages[j].page
+		})
+	}
+
+	for _, page := range questionPages {
+		questions = append(questions, page.path)
+	}
+
+	for _, page := range answerPages {
+		answers = append(answers, page.path)
+	}
+
+	// Alternatives are numbered from 1, but there could be gaps if one has been removed by hand.
+	last := 0
+	for _, page := range alternativePages {
+		if page.alternative != last {
+			alternatives = append(alternatives, []string{})
+			last = page.alternative
+		}
+
+		alternatives[len(alternatives)-1] = append(alternatives[len(alternatives)-1], page.path)
+	}
+
+	return questions, answers, alternatives
+}
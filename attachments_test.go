@@ -0,0 +1,53 @@
+package sergeant
+
+import (
+	"testing"
+
+	"github.com/albatross-org/go-albatross/entries"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCardPagesFromAttachments tests that a card's attachments are sorted into the pages of the question, answer and
+// alternative solutions.
+func TestCardPagesFromAttachments(t *testing.T) {
+	attachments := []entries.Attachment{}
+	for _, name := range []string{
+		"answer-2.webp", "question.png", "answer-1.png", "answer-10.png",
+		"alternative-2.svg", "alternative-1-2.pdf", "alternative-1-1.jpg",
+		"notes.txt", "question-draft.png", "answer.txt",
+	} {
+		attachments = append(attachments, entries.Attachment{AbsPath: "/entry/" + name, Name: name})
+	}
+
+	questions, answers, alternatives := cardPagesFromAttachments(attachments)
+
+	assert.Equal(t, []string{"/entry/question.png"}, questions)
+	assert.Equal(t, []string{"/entry/answer-1.png", "/entry/answer-2.webp", "/entry/answer-10.png"}, answers, "expected pages to be in numerical order")
+	assert.Equal(t, [][]string{
+		{"/entry/alternative-1-1.jpg", "/entry/alternative-1-2.pdf"},
+		{"/entry/alternative-2.svg"},
+	}, alternatives)
+}
+
+// TestAttachmentName tests that pages are only numbered when there's more than one of them.
+func TestAttachmentName(t *testing.T) {
+	assert.Equal(t, "question.png", AttachmentName("question", 1, 1, ".png"))
+	assert.Equal(t, "answer-2.jpg", AttachmentName("answer", 2, 3, ".jpg"))
+	assert.Equal(t, "alternative-1-2.pdf", AttachmentName(AlternativeKind(1), 2, 2, ".pdf"))
+}
+
+// TestMimeFromIncipit tests that the media types that can be attached to cards are recognised.
+func TestMimeFromIncipit(t *testing.T) {
+	testCases := map[string]string{
+		"\x89PNG\r\n\x1a\n\x00\x00":    "image/png",
+		"RIFF\x24\x00\x00\x00WEBPVP8 ": "image/webp",
+		"RIFF\x24\x00\x00\x00WAVEfmt ": "",
+		"%PDF-1.7\n":                   "application/pdf",
+		"<?xml version=\"1.0\"?>\n<svg xmlns=\"http://www.w3.org/2000/svg\">": "image/svg+xml",
+		"Just some notes.": "",
+	}
+
+	for incipit, mime := range testCases {
+		assert.Equal(t, mime, mimeFromIncipit([]byte(incipit)), "expected different mime type for %q", incipit)
+	}
+}
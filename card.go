@@ -2,8 +2,10 @@ package sergeant
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -11,6 +13,12 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
+// ErrNoCard is returned when there isn't a card with the ID being looked up, such as when it has been deleted.
+var ErrNoCard = errors.New("no such card")
+
+// ErrNoCompletion is returned when a completion that's being removed or changed can't be found on a card.
+var ErrNoCompletion = errors.New("no such completion")
+
 // Card is the basic unit of the program. It's an abstraction over an Albatross entry and represents a question-answer pair.
 type Card struct {
 	ID   string
@@ -24,8 +32,26 @@ type Card struct {
 	CompletionsMinor   []Completion
 	CompletionsMajor   []Completion
 
-	QuestionPath string
-	AnswerPath   string
+	// Profiles holds the completions made by every profile other than the default one, by profile name. The
+	// completions above belong to the default profile. Use ForProfile to see the card as a profile would.
+	Profiles map[string]ProfileCompletions
+
+	// QuestionPath and AnswerPath are the first pages of the question and answer. QuestionPaths and AnswerPaths are
+	// every page, in order, since long questions and worked solutions can run over more than one image.
+	QuestionPath  string
+	AnswerPath    string
+	QuestionPaths []string
+	AnswerPaths   []string
+
+	// Alternatives are other solutions to the question, each of which can have more than one page.
+	Alternatives [][]string
+}
+
+// ProfileCompletions are the completions of a card made by a single profile.
+type ProfileCompletions struct {
+	Perfect []Completion
+	Minor   []Completion
+	Major   []Completion
 }
 
 // Completion is a mark specifying that a card was completed at a certain date in a certain amount of time.
@@ -34,26 +60,182 @@ type Completion struct {
 	Duration time.Duration `yaml:"time"`
 }
 
+// TypedCompletion is a Completion paired with the type of completion it was ("perfect", "minor" or "major").
+type TypedCompletion struct {
+	Type string
+	Completion
+}
+
 // PathParent returns the path to the parent of the card. You could think of this as the card category.
 func (card *Card) PathParent() string {
 	return filepath.Dir(card.Path)
 }
 
-// QuestionImage returns the data URI of the question image, created by converting the file to base64.
+// QuestionImage returns the data URI of the first page of the question, created by converting the file to base64.
 func (card *Card) QuestionImage() (string, error) {
 	return encodeAsDataURI(card.QuestionPath)
 }
 
-// AnswerImage returns the data URI of the answer image, created by converting the file to base64.
+// AnswerImage returns the data URI of the first page of the answer, created by converting the file to base64.
 func (card *Card) AnswerImage() (string, error) {
 	return encodeAsDataURI(card.AnswerPath)
 }
 
+// ForProfile returns the card as seen by the profile with the given name, with the profile's completions in place of
+// the default profile's. For the default profile, the card itself is returned.
+// The card returned shares its other fields with the original and shouldn't be modified.
+func (card *Card) ForProfile(name string) *Card {
+	if name == DefaultProfile {
+		return card
+	}
+
+	completions := card.Profiles[name]
+
+	profileCard := *card
+	profileCard.CompletionsPerfect = completions.Perfect
+	profileCard.CompletionsMinor = completions.Minor
+	profileCard.CompletionsMajor = completions.Major
+
+	return &profileCard
+}
+
+// setProfileCompletions replaces the completions made by the profile with the given name with the completions of
+// from, which should be a card returned by ForProfile.
+func (card *Card) setProfileCompletions(name string, from *Card) {
+	if name == DefaultProfile {
+		card.CompletionsPerfect = from.CompletionsPerfect
+		card.CompletionsMinor = from.CompletionsMinor
+		card.CompletionsMajor = from.CompletionsMajor
+
+		return
+	}
+
+	profiles := map[string]ProfileCompletions{}
+	for existingName, completions := range card.Profiles {
+		profiles[existingName] = completions
+	}
+
+	profiles[name] = ProfileCompletions{
+		Perfect: from.CompletionsPerfect,
+		Minor:   from.CompletionsMinor,
+		Major:   from.CompletionsMajor,
+	}
+
+	card.Profiles = profiles
+}
+
 // TotalCompletions returns the total number of completions for this card.
 func (card *Card) TotalCompletions() int {
 	return len(card.CompletionsMajor) + len(card.CompletionsMinor) + len(card.CompletionsPerfect)
 }
 
+// History returns every completion of the card, regardless of type, ordered from oldest to newest.
+func (card *Card) History() []TypedCompletion {
+	history := []TypedCompletion{}
+
+	for _, completion := range card.CompletionsPerfect {
+		history = append(history, TypedCompletion{Type: "perfect", Completion: completion})
+	}
+
+	for _, completion := range card.CompletionsMinor {
+		history = append(history, TypedCompletion{Type: "minor", Completion: completion})
+	}
+
+	for _, completion := range card.CompletionsMajor {
+		history = append(history, TypedCompletion{Type: "major", Completion: completion})
+	}
+
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].Date.Before(history[j].Date)
+	})
+
+	return history
+}
+
+// LastCompletion returns the card's most recent completion. If the card has never been completed, it returns false.
+func (card *Card) LastCompletion() (TypedCompletion, bool) {
+	history := card.History()
+	if len(history) == 0 {
+		return TypedCompletion{}, false
+	}
+
+	return history[len(history)-1], true
+}
+
+// MedianDuration returns the median time taken to complete the card, across completions of every type.
+// If the card has never been completed, it returns zero.
+func (card *Card) MedianDuration() time.Duration {
+	durations := []time.Duration{}
+	for _, completion := range card.History() {
+		durations = append(durations, completion.Duration)
+	}
+
+	if len(durations) == 0 {
+		return 0
+	}
+
+	sort.Slice(durations, func(i, j int) bool {
+		return durations[i] < durations[j]
+	})
+
+	middle := len(durations) / 2
+	if len(durations)%2 == 0 {
+		return (durations[middle-1] + durations[middle]) / 2
+	}
+
+	return durations[middle]
+}
+
+// addCompletion adds a completion of the given type ("perfect", "minor" or "major") to the card.
+func (card *Card) addCompletion(completionType string, completion Completion) error {
+	switch completionType {
+	case "perfect":
+		card.CompletionsPerfect = append(card.CompletionsPerfect, completion)
+	case "minor":
+		card.CompletionsMinor = append(card.CompletionsMinor, completion)
+	case "major":
+		card.CompletionsMajor = append(card.CompletionsMajor, completion)
+	default:
+		return fmt.Errorf("invalid completion type %q", completionType)
+	}
+
+	return nil
+}
+
+// removeCompletion removes the completion made at the given date from the card and returns it.
+// Since completions are only stored to the nearest minute, dates are compared as they would be written in the entry.
+// If more than one completion was made in the same minute, the latest one is removed. If the date is the zero time,
+// the card's most recent completion is removed.
+func (card *Card) removeCompletion(date time.Time) (TypedCompletion, error) {
+	history := card.History()
+
+	index := -1
+	for i := len(history) - 1; i >= 0; i-- {
+		if date.IsZero() || history[i].Date.Format("2006-01-02 15:04") == date.Format("2006-01-02 15:04") {
+			index = i
+			break
+		}
+	}
+
+	if index == -1 {
+		if date.IsZero() {
+			return TypedCompletion{}, fmt.Errorf("card %q has no completions: %w", card.ID, ErrNoCompletion)
+		}
+
+		return TypedCompletion{}, fmt.Errorf("card %q has no completion at %s: %w", card.ID, date.Format("2006-01-02 15:04"), ErrNoCompletion)
+	}
+
+	removed := history[index]
+	history = append(history[:index], history[index+1:]...)
+
+	card.CompletionsPerfect, card.CompletionsMinor, card.CompletionsMajor = nil, nil, nil
+	for _, completion := range history {
+		card.addCompletion(completion.Type, completion.Completion)
+	}
+
+	return removed, nil
+}
+
 // Content returns how the card is represented as an entry. Think of it like the opposite of cardFromEntry.
 func (card *Card) Content() (string, error) {
 	type frontmatter struct {
@@ -62,6 +244,7 @@ func (card *Card) Content() (string, error) {
 		Tags        []string `yaml:"tags"`
 		Date        string   `yaml:"date"`
 		Completions map[string][]map[string]string
+		Profiles    map[string]map[string][]map[string]string `yaml:"profiles,omitempty"`
 	}
 
 	entryFrontmatter := frontmatter{
@@ -76,6 +259,18 @@ func (card *Card) Content() (string, error) {
 		Date: card.Date.Format("2006-01-02 15:04"),
 	}
 
+	if len(card.Profiles) > 0 {
+		entryFrontmatter.Profiles = map[string]map[string][]map[string]string{}
+
+		for name, completions := range card.Profiles {
+			entryFrontmatter.Profiles[name] = map[string][]map[string]string{
+				"perfect": completionToStringMap(completions.Perfect),
+				"minor":   completionToStringMap(completions.Minor),
+				"major":   completionToStringMap(completions.Major),
+			}
+		}
+	}
+
 	frontmatterBytes, err := yaml.Marshal(entryFrontmatter)
 	if err != nil {
 		return "", fmt.Errorf("couldn't marshal new entry frontmatter: %w", err)
@@ -110,6 +305,11 @@ func (card *Card) Content() (string, error) {
 //             time: 5m53s
 //           - date: 2021-02-16 10:18
 //             time: 5m53s
+//   profiles:                                     // This becomes the .Profiles field, and is optional.
+//       alice:
+//           perfect:
+//               - date: 2021-02-16 10:18
+//                 time: 6m02s
 //   ---
 //   Any additional notes about the card (This becomes the .Notes field).
 func cardFromEntry(entry *entries.Entry) (*Card, error) {
@@ -171,30 +371,71 @@ func cardFromEntry(entry *entries.Entry) (*Card, error) {
 	card.CompletionsMinor = completionsMinor
 	card.CompletionsMajor = completionsMajor
 
-	// Verify that a question and answer are attached and set them.
-	var questionPath, answerPath string
-	for _, attachment := range entry.Attachments {
-		if strings.HasPrefix(attachment.Name, "question.") {
-			questionPath = attachment.AbsPath
-		} else if strings.HasPrefix(attachment.Name, "answer.") {
-			answerPath = attachment.AbsPath
+	// Completions made by other profiles are optional, since most stores only have one learner.
+	if profilesInterface, ok := entry.Metadata["profiles"]; ok && profilesInterface != nil {
+		card.Profiles, err = profilesFromMetadata(profilesInterface)
+		if err != nil {
+			return nil, err
 		}
 	}
 
-	if questionPath == "" {
+	// Verify that a question and answer are attached and set them.
+	questionPaths, answerPaths, alternatives := cardPagesFromAttachments(entry.Attachments)
+
+	if len(questionPaths) == 0 {
 		return nil, fmt.Errorf("card entry has no 'question' attachment")
 	}
 
-	if answerPath == "" {
+	if len(answerPaths) == 0 {
 		return nil, fmt.Errorf("card entry has no 'answer' attachment")
 	}
 
-	card.QuestionPath = questionPath
-	card.AnswerPath = answerPath
+	card.QuestionPath = questionPaths[0]
+	card.AnswerPath = answerPaths[0]
+	card.QuestionPaths = questionPaths
+	card.AnswerPaths = answerPaths
+	card.Alternatives = alternatives
 
 	return card, nil
 }
 
+// profilesFromMetadata converts the 'profiles' field of a card's metadata, which maps profile names to completions in
+// the same format as the 'completions' field, into a map of profile names to ProfileCompletions.
+func profilesFromMetadata(profilesInterface interface{}) (map[string]ProfileCompletions, error) {
+	profilesMap, ok := profilesInterface.(map[interface{}]interface{})
+	if !ok {
+		return nil, fmt.Errorf("couldn't parse 'profiles' in card entry metadata, got %T instead of a map", profilesInterface)
+	}
+
+	profiles := map[string]ProfileCompletions{}
+
+	for key, value := range profilesMap {
+		name, ok := key.(string)
+		if !ok {
+			return nil, fmt.Errorf("couldn't parse 'profiles' in card entry metadata, profile name %q not a string", key)
+		}
+
+		completionsMapInterface, ok := value.(map[interface{}]interface{})
+		if !ok {
+			return nil, fmt.Errorf("couldn't parse completions for profile %q in card entry metadata", name)
+		}
+
+		completionsMap, err := completionsMapInterfaceToTypedMap(completionsMapInterface)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't parse completions for profile %q: %w", name, err)
+		}
+
+		perfect, minor, major, err := completionsMapToStruct(completionsMap)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't parse completions for profile %q: %w", name, err)
+		}
+
+		profiles[name] = ProfileCompletions{Perfect: perfect, Minor: minor, Major: major}
+	}
+
+	return profiles, nil
+}
+
 // completionsMapInterfaceToTypedMap converts a map[interface{}]interface{} to a map[string][]map[string]string, the format ready to be used
 // by the rest of the program.
 // I feel like there's a much better way of doing this and the variable names make me want to be sick. Is it really neccessary to cast this many
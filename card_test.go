@@ -710,3 +710,41 @@ func assertCompletionsEqual(t *testing.T, completionType string, completions1 []
 		assert.Equal(t, completion1.Duration.String(), completion2.Duration.String(), "expected %q completions to have same duration", completionType)
 	}
 }
+
+// TestCardRemoveCompletion tests that card.removeCompletion removes the correct completion.
+func TestCardRemoveCompletion(t *testing.T) {
+	first := time.Date(2021, 02, 16, 10, 18, 0, 0, time.UTC)
+	second := time.Date(2021, 02, 17, 9, 30, 0, 0, time.UTC)
+	third := time.Date(2021, 02, 18, 17, 45, 0, 0, time.UTC)
+
+	newCard := func() *Card {
+		return &Card{
+			ID:                 "BtIrmFTJo49QuJC4",
+			CompletionsPerfect: []Completion{{Date: first, Duration: time.Minute}},
+			CompletionsMinor:   []Completion{{Date: third, Duration: 3 * time.Minute}},
+			CompletionsMajor:   []Completion{{Date: second, Duration: 2 * time.Minute}},
+		}
+	}
+
+	card := newCard()
+	removed, err := card.removeCompletion(time.Time{})
+	assert.NoError(t, err, "not expecting error removing most recent completion")
+	assert.Equal(t, "minor", removed.Type, "expected most recent completion to be removed")
+	assert.Len(t, card.CompletionsMinor, 0, "expected minor completion to be removed")
+	assert.Equal(t, 2, card.TotalCompletions(), "expected other completions to remain")
+
+	card = newCard()
+	removed, err = card.removeCompletion(time.Date(2021, 02, 17, 9, 30, 45, 0, time.Local))
+	assert.NoError(t, err, "not expecting error removing completion by date")
+	assert.Equal(t, "major", removed.Type, "expected completion at the date given to be removed")
+	assert.Equal(t, 2*time.Minute, removed.Duration, "expected completion at the date given to be removed")
+	assert.Len(t, card.CompletionsMajor, 0, "expected major completion to be removed")
+
+	_, err = card.removeCompletion(time.Date(2020, 01, 01, 0, 0, 0, 0, time.UTC))
+	assert.EqualError(t, err, `card "BtIrmFTJo49QuJC4" has no completion at 2020-01-01 00:00: no such completion`, "expected error for missing completion")
+	assert.ErrorIs(t, err, ErrNoCompletion)
+
+	_, err = (&Card{ID: "empty"}).removeCompletion(time.Time{})
+	assert.EqualError(t, err, `card "empty" has no completions: no such completion`, "expected error for card with no completions")
+	assert.ErrorIs(t, err, ErrNoCompletion)
+}
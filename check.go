@@ -0,0 +1,260 @@
+package sergeant
+
+import (
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigProblem is a problem found in a config file by CheckConfig, along with where in the file it is.
+type ConfigProblem struct {
+	Path    string
+	Line    int
+	Column  int
+	Message string
+}
+
+// String returns the problem in the usual "file:line:column: message" format.
+func (problem ConfigProblem) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s", problem.Path, problem.Line, problem.Column, problem.Message)
+}
+
+// CheckConfig looks for problems in the config file at the given path that LoadConfig either doesn't notice or can't
+// say where they are, such as unknown keys, invalid durations and dates, and sets named "all".
+// If cards is not nil, it also checks that every path in a set matches at least one of the cards and that every tag
+// in a set is on at least one of them, since typos in either silently make sets empty.
+// The error is only returned if the file couldn't be read or isn't valid YAML at all.
+func CheckConfig(path string, cards []*Card) ([]ConfigProblem, error) {
+	if path == "" {
+		path = DefaultConfigPath()
+	}
+
+	contentBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read config located at %q: %w", path, err)
+	}
+
+	doc := yaml.Node{}
+
+	err = yaml.Unmarshal(contentBytes, &doc)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't unmarshal config located at %q: %w", path, err)
+	}
+
+	checker := &configChecker{path: path, cards: cards, problems: []ConfigProblem{}}
+
+	if len(doc.Content) > 0 {
+		checker.checkRoot(doc.Content[0])
+	}
+
+	return checker.problems, nil
+}
+
+// configChecker collects problems while walking through the nodes of a config file.
+type configChecker struct {
+	path     string
+	cards    []*Card
+	problems []ConfigProblem
+}
+
+// add records a problem at the position of the node given.
+func (checker *configChecker) add(node *yaml.Node, format string, args ...interface{}) {
+	checker.problems = append(checker.problems, ConfigProblem{
+		Path:    checker.path,
+		Line:    node.Line,
+		Column:  node.Column,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// pairs calls fn with each key and value in a mapping node. If the node isn't a mapping, a problem is recorded instead.
+func (checker *configChecker) pairs(node *yaml.Node, what string, fn func(key, value *yaml.Node)) {
+	if node.Kind != yaml.MappingNode {
+		checker.add(node, "%s should be a mapping", what)
+		return
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		fn(node.Content[i], node.Content[i+1])
+	}
+}
+
+// checkRoot checks the top level of the config.
+func (checker *configChecker) checkRoot(root *yaml.Node) {
+	known := yamlKeys(rawConfigDef{})
+
+	checker.pairs(root, "the config", func(key, value *yaml.Node) {
+		switch key.Value {
+		case "reload-interval":
+			checker.checkDuration(value, key.Value, time.ParseDuration)
+		case "recent-duration":
+			checker.checkDuration(value, key.Value, parseDuration)
+		case "recent-cards":
+			if n, err := strconv.Atoi(value.Value); err != nil || n < 0 {
+				checker.add(value, "recent-cards should be a whole number that isn't negative, got %q", value.Value)
+			}
+
+		case "server":
+			checker.checkServer(value)
+		case "sets":
+			checker.pairs(value, "sets", checker.checkSet)
+		case "views":
+			checker.pairs(value, "views", checker.checkView)
+
+		default:
+			if !known[key.Value] {
+				checker.add(key, "unknown key %q", key.Value)
+			}
+		}
+	})
+}
+
+// checkServer checks the options for the web server.
+func (checker *configChecker) checkServer(server *yaml.Node) {
+	known := yamlKeys(rawConfigServerDef{})
+
+	checker.pairs(server, "server", func(key, value *yaml.Node) {
+		switch key.Value {
+		case "session-duration":
+			checker.checkDuration(value, key.Value, parseDuration)
+
+		case "tokens":
+			for _, item := range sequenceItems(value) {
+				if item.Value == "" {
+					checker.add(item, "tokens in server can't be blank")
+				}
+			}
+
+		default:
+			if !known[key.Value] {
+				checker.add(key, "unknown key %q in server", key.Value)
+			}
+		}
+	})
+}
+
+// checkSet checks the definition of a single set.
+func (checker *configChecker) checkSet(name, set *yaml.Node) {
+	if name.Value == "all" {
+		checker.add(name, "the set name %q collides with the built-in set", name.Value)
+	}
+
+	known := yamlKeys(rawConfigSetDef{})
+
+	checker.pairs(set, fmt.Sprintf("the %q set", name.Value), func(key, value *yaml.Node) {
+		switch key.Value {
+		case "before-duration", "after-duration", "median-time-over", "last-answered-before-duration", "last-answered-after-duration":
+			checker.checkDuration(value, key.Value, parseDuration)
+
+		case "before-date", "after-date", "last-answered-before-date", "last-answered-after-date":
+			if _, err := time.Parse("2006-01-02 15:04", value.Value); err != nil {
+				checker.add(value, "invalid %s %q: expected a date like 2021-02-16 10:18", key.Value, value.Value)
+			}
+
+		case "query":
+			if _, err := ParseFilter(value.Value); err != nil {
+				checker.add(value, "invalid query %q: %s", value.Value, err)
+			}
+
+		case "last-result":
+			for _, item := range sequenceItems(value) {
+				if item.Value != "perfect" && item.Value != "minor" && item.Value != "major" {
+					checker.add(item, "invalid last-result %q: please use 'perfect', 'minor' or 'major'", item.Value)
+				}
+			}
+
+		case "paths", "paths-and", "exclude-paths":
+			if checker.cards == nil {
+				break
+			}
+
+			for _, item := range sequenceItems(value) {
+				if !checker.anyCard(FilterPaths(item.Value)) {
+					checker.add(item, "the path %q in %s doesn't match any cards", item.Value, key.Value)
+				}
+			}
+
+		case "tags", "tags-and", "exclude-tags":
+			if checker.cards == nil {
+				break
+			}
+
+			for _, item := range sequenceItems(value) {
+				if !checker.anyCard(FilterTags(item.Value)) {
+					checker.add(item, "the tag %q in %s isn't on any cards", item.Value, key.Value)
+				}
+			}
+
+		default:
+			if !known[key.Value] {
+				checker.add(key, "unknown key %q in the %q set", key.Value, name.Value)
+			}
+		}
+	})
+}
+
+// checkView checks the definition of a single view. The parameters themselves are checked by LoadConfig.
+func (checker *configChecker) checkView(name, view *yaml.Node) {
+	known := yamlKeys(rawConfigViewDef{})
+
+	checker.pairs(view, fmt.Sprintf("the %q view", name.Value), func(key, value *yaml.Node) {
+		switch key.Value {
+		case "first-interval", "second-interval":
+			checker.checkDuration(value, key.Value, parseDuration)
+
+		default:
+			if !known[key.Value] {
+				checker.add(key, "unknown key %q in the %q view", key.Value, name.Value)
+			}
+		}
+	})
+}
+
+// checkDuration records a problem if a node's value can't be parsed by the function given.
+func (checker *configChecker) checkDuration(node *yaml.Node, field string, parse func(string) (time.Duration, error)) {
+	if _, err := parse(node.Value); err != nil {
+		checker.add(node, "invalid %s %q: expected a duration like 10m", field, node.Value)
+	}
+}
+
+// anyCard reports whether any of the cards are allowed by the filter.
+func (checker *configChecker) anyCard(filter Filter) bool {
+	for _, card := range checker.cards {
+		if filter(card) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sequenceItems returns the items in a sequence node, or the node itself if it's a single value.
+func sequenceItems(node *yaml.Node) []*yaml.Node {
+	if node.Kind == yaml.SequenceNode {
+		return node.Content
+	}
+
+	return []*yaml.Node{node}
+}
+
+// yamlKeys returns the keys a struct is unmarshalled from, taken from its yaml struct tags.
+func yamlKeys(v interface{}) map[string]bool {
+	keys := map[string]bool{}
+
+	t := reflect.TypeOf(v)
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("yaml")
+		if tag == "" || tag == "-" {
+			continue
+		}
+
+		keys[strings.Split(tag, ",")[0]] = true
+	}
+
+	return keys
+}
@@ -0,0 +1,68 @@
+package sergeant
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCheckConfig tests that problems in the config are found along with where they are.
+func TestCheckConfig(t *testing.T) {
+	file, err := ioutil.TempFile("", "sergeant-config-*.yaml")
+	if err != nil {
+		t.Fatalf("couldn't create temporary file: %s", err)
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.WriteString(`reload-interval: 1m
+recent-duraton: 10m
+sets:
+    all:
+        name: Everything
+    series:
+        paths-and:
+            - maths/series
+            - maths/sereis
+        tags:
+            - '@?hard'
+        before-date: yesterday
+        last-answered-before-duration: 1 week
+views:
+    slow:
+        type: spaced
+        first-interval: soon
+`)
+	file.Close()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	cards := []*Card{
+		{Path: "maths/series/ex1/question-1", Tags: []string{"@?hard"}},
+	}
+
+	problems, err := CheckConfig(file.Name(), cards)
+	if !assert.NoError(t, err, "not expecting error checking config") {
+		return
+	}
+
+	positions := map[int]int{}
+	for _, problem := range problems {
+		positions[problem.Line] = problem.Column
+	}
+
+	assert.Len(t, problems, 6, "expected every problem to be found: %v", problems)
+	assert.Equal(t, 1, positions[2], "expected unknown key to be found")
+	assert.Equal(t, 5, positions[4], "expected set named all to be found")
+	assert.Equal(t, 15, positions[9], "expected path without any cards to be found")
+	assert.Equal(t, 22, positions[12], "expected invalid date to be found")
+	assert.Equal(t, 40, positions[13], "expected invalid duration to be found")
+	assert.Equal(t, 25, positions[17], "expected invalid view duration to be found")
+
+	problems, err = CheckConfig(file.Name(), nil)
+	if assert.NoError(t, err, "not expecting error checking config") {
+		assert.Len(t, problems, 5, "expected paths not to be checked without cards")
+	}
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/albatross-org/go-albatross/albatross"
@@ -14,7 +15,7 @@ import (
 
 // addCmd represents the 'add' command.
 var addCmd = &cobra.Command{
-	Use:   "add --path [path] --question [question image] --answer [answer image]",
+	Use:   "add --path [path] --question [question image]... --answer [answer image]... (--alternative [images])",
 	Short: "Add a card",
 	Long: `Add lets you add a card to the database of questions.
 	
@@ -32,6 +33,17 @@ You can also add tags:
 		-a 'answer.png' \
 		-t @?school -t @?further-maths 
 
+Questions and answers that run over several pages can be given one page at a time, in order. Other ways of solving the
+question can be added as alternatives, with the pages of each alternative separated by commas:
+
+	$ sergeant add \
+		-p 'further-maths/core-pure-1/chapter-1-complex-numbers' \
+		-q 'question.png' \
+		-a 'answer-page-1.png' -a 'answer-page-2.png' \
+		--alternative 'other-method-1.png,other-method-2.png'
+
+Images can be PNG, JPEG, GIF, SVG or WebP files, and pages can also be PDFs.
+
 This is pretty longwinded and slow to use manually. If you want to scan in lots of questions very quickly, it's much easier to use
 the 'screenshot' command:
 
@@ -62,16 +74,24 @@ For more information, see:
 		path, err := cmd.Flags().GetString("path")
 		checkFlag(err, "--path", "add")
 
-		questionPath, err := cmd.Flags().GetString("question")
+		questionPaths, err := cmd.Flags().GetStringArray("question")
 		checkFlag(err, "--question", "add")
 
-		answerPath, err := cmd.Flags().GetString("answer")
+		answerPaths, err := cmd.Flags().GetStringArray("answer")
 		checkFlag(err, "--answer", "add")
 
+		alternatives, err := cmd.Flags().GetStringArray("alternative")
+		checkFlag(err, "--alternative", "add")
+
 		tags, err := cmd.Flags().GetStringSlice("tags")
 		checkFlag(err, "--tags", "add")
 
-		entryPath, err := createCard(store, path, tags, questionPath, answerPath)
+		pages := cardPages{questions: questionPaths, answers: answerPaths}
+		for _, alternative := range alternatives {
+			pages.alternatives = append(pages.alternatives, strings.Split(alternative, ","))
+		}
+
+		entryPath, err := createCard(store, path, tags, pages)
 		if err != nil {
 			logrus.Fatal(err)
 		}
@@ -82,24 +102,61 @@ For more information, see:
 	},
 }
 
-// createCard creates a card with at the given path and tags with the question and answer as an attachment.
-// It returns the path to the new card and an error if there was one.
-func createCard(store *albatross.Store, path string, tags []string, questionPath, answerPath string) (string, error) {
-	if !exists(questionPath) {
-		return "", fmt.Errorf("path to question image does not exist")
-	}
+// cardPages are the images of a card that's about to be created, in order.
+type cardPages struct {
+	questions    []string
+	answers      []string
+	alternatives [][]string
+}
 
-	if !exists(answerPath) {
-		return "", fmt.Errorf("path to answer image does not exist")
-	}
+// singlePage returns the cardPages for a card with a single question and answer image.
+func singlePage(questionPath, answerPath string) cardPages {
+	return cardPages{questions: []string{questionPath}, answers: []string{answerPath}}
+}
 
+// createCard creates a card with at the given path and tags with the pages of the question, answer and any
+// alternatives as attachments. It returns the path to the new card and an error if there was one.
+func createCard(store *albatross.Store, path string, tags []string, pages cardPages) (string, error) {
 	// We can omit lots of fields here since they won't be used to generate the entry content.
 	card := &sergeant.Card{
-		ID:   randomString(16),
 		Date: time.Now(),
 		Tags: tags,
 	}
 
+	return createCardFrom(store, card, path, pages)
+}
+
+// createCardFrom is like createCard, but uses the date, tags and completions of the card given. This is used when
+// importing cards that already have a history. The card is given a new ID.
+func createCardFrom(store *albatross.Store, card *sergeant.Card, path string, pages cardPages) (string, error) {
+	if len(pages.questions) == 0 {
+		return "", fmt.Errorf("no question image given")
+	}
+
+	if len(pages.answers) == 0 {
+		return "", fmt.Errorf("no answer image given")
+	}
+
+	// Every page is checked before the entry is created so that a typo doesn't leave a card without its images.
+	kinds := map[string][]string{"question": pages.questions, "answer": pages.answers}
+	for i, alternative := range pages.alternatives {
+		kinds[sergeant.AlternativeKind(i+1)] = alternative
+	}
+
+	for kind, paths := range kinds {
+		for _, imagePath := range paths {
+			if !exists(imagePath) {
+				return "", fmt.Errorf("path to %s image %q does not exist", kind, imagePath)
+			}
+
+			if !sergeant.IsMediaFile(imagePath) {
+				return "", fmt.Errorf("can't use %q as a %s image: the file type isn't supported", imagePath, kind)
+			}
+		}
+	}
+
+	card.ID = randomString(16)
+
 	content, err := card.Content()
 	if err != nil {
 		return "", fmt.Errorf("couldn't get card content: %s", err)
@@ -115,18 +172,16 @@ func createCard(store *albatross.Store, path string, tags []string, questionPath
 	}
 
 	// It might be that the question or answer image is called something like "screenshot.png". Entries are expected to have
-	// question/answer attachments in the form "question.png" or "answer.jpg" so we need to create those here.
-	attachmentQuestionPath := "question" + filepath.Ext(questionPath)
-	attachmentAnswerPath := "answer" + filepath.Ext(answerPath)
-
-	err = store.AttachCopyWithName(entryPath, questionPath, attachmentQuestionPath)
-	if err != nil {
-		return "", fmt.Errorf("couldn't attach question image %q: %w", questionPath, err)
-	}
-
-	err = store.AttachCopyWithName(entryPath, answerPath, attachmentAnswerPath)
-	if err != nil {
-		return "", fmt.Errorf("couldn't attach answer image %q: %w", answerPath, err)
+	// question/answer attachments in the form "question.png" or "answer-2.jpg" so we need to create those here.
+	for kind, paths := range kinds {
+		for i, imagePath := range paths {
+			name := sergeant.AttachmentName(kind, i+1, len(paths), strings.ToLower(filepath.Ext(imagePath)))
+
+			err = store.AttachCopyWithName(entryPath, imagePath, name)
+			if err != nil {
+				return "", fmt.Errorf("couldn't attach %s image %q: %w", kind, imagePath, err)
+			}
+		}
 	}
 
 	return entryPath, nil
@@ -134,8 +189,9 @@ func createCard(store *albatross.Store, path string, tags []string, questionPath
 
 func init() {
 	addCmd.Flags().StringP("path", "p", "", "path to where the card should go")
-	addCmd.Flags().StringP("question", "q", "", "path to the question image")
-	addCmd.Flags().StringP("answer", "a", "", "path to the answer image")
+	addCmd.Flags().StringArrayP("question", "q", []string{}, "path to the question image, given more than once for questions with several pages")
+	addCmd.Flags().StringArrayP("answer", "a", []string{}, "path to the answer image, given more than once for answers with several pages")
+	addCmd.Flags().StringArray("alternative", []string{}, "comma-separated paths to the pages of an alternative solution, given once for each alternative")
 
 	addCmd.Flags().StringSliceP("tags", "t", []string{}, "tags to add to the entry created")
 
@@ -14,7 +14,7 @@ import (
 
 // completeCmd represents the 'complete' command.
 var completeCmd = &cobra.Command{
-	Use:   "complete --path [path to question] --time [amount of time taken] (perfect|minor|major)",
+	Use:   "complete --path [path to question] (--time [amount of time taken] (perfect|minor|major) | --undo)",
 	Short: "Complete a card",
 	Long: `Complete lets you manually add a completion to a card using the command line rather than the Web UI.
 	
@@ -23,9 +23,32 @@ For example:
 	$ sergeant complete perfect --path 'further-maths/core-pure-1/chapter-1-complex-numbers/mixed-exercise-1/question-abcdef' --time "3m47s" 
 	# Or, using the short versions of the flags:
 	$ sergeant complete perfect -p 'further-maths/core-pure-1/chapter-1-complex-numbers/mixed-exercise-1/question-abcdef' -t "3m47s"
+
+If you add the wrong completion by mistake, you can undo it. This removes the most recent completion:
+
+	$ sergeant complete --undo -p 'further-maths/core-pure-1/chapter-1-complex-numbers/mixed-exercise-1/question-abcdef'
+
+Or you can remove a specific completion by specifying when it was made:
+
+	$ sergeant complete --undo --date "2021-02-16 10:18" -p 'further-maths/core-pure-1/chapter-1-complex-numbers/mixed-exercise-1/question-abcdef'
+
+If more than one person is using the same store, completions can be added to a specific profile:
+
+	$ sergeant complete minor --profile alice -p 'further-maths/core-pure-1/chapter-1-complex-numbers/mixed-exercise-1/question-abcdef' -t "5m02s"
 	`,
-	Args:      cobra.ExactValidArgs(1),
 	ValidArgs: []string{"perfect", "minor", "major"},
+	Args: func(cmd *cobra.Command, args []string) error {
+		undo, err := cmd.Flags().GetBool("undo")
+		if err != nil {
+			return err
+		}
+
+		if undo {
+			return cobra.NoArgs(cmd, args)
+		}
+
+		return cobra.ExactValidArgs(1)(cmd, args)
+	},
 
 	Run: func(cmd *cobra.Command, args []string) {
 		configPath, err := cmd.Flags().GetString("config")
@@ -45,13 +68,32 @@ For example:
 
 		store := sergeant.NewStore(underlyingStore, config)
 
+		profileName, err := cmd.Flags().GetString("profile")
+		checkFlag(err, "--profile", "complete")
+
+		err = sergeant.ValidateProfileName(profileName)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		profile := store.Profile(profileName)
+
 		path, err := cmd.Flags().GetString("path")
 		checkFlag(err, "--path", "complete")
 
+		undo, err := cmd.Flags().GetBool("undo")
+		checkFlag(err, "--undo", "complete")
+
+		if undo {
+			undoCompletion(cmd, profile, path)
+			return
+		}
+
 		timeTaken, err := cmd.Flags().GetDuration("time")
 		checkFlag(err, "--time", "complete")
 
-		err = store.AddCompletion(path, args[0], sergeant.Completion{
+		err = profile.AddCompletion(path, args[0], sergeant.Completion{
 			Date:     time.Now(),
 			Duration: timeTaken,
 		})
@@ -66,9 +108,44 @@ For example:
 	},
 }
 
+// undoCompletion removes one of the profile's completions from the card at the given path, as specified by the --date
+// flag.
+func undoCompletion(cmd *cobra.Command, profile *sergeant.Profile, path string) {
+	rawDate, err := cmd.Flags().GetString("date")
+	checkFlag(err, "--date", "complete")
+
+	var date time.Time
+	if rawDate != "" {
+		date, err = time.Parse("2006-01-02 15:04", rawDate)
+		if err != nil {
+			fmt.Printf("Invalid date %q, expecting the format '2006-01-02 15:04': %s\n", rawDate, err)
+			os.Exit(1)
+		}
+	}
+
+	card, err := profile.CardByPath(path)
+	if err != nil {
+		fmt.Printf("Error getting card %q: %s\n", path, err)
+		os.Exit(1)
+	}
+
+	removed, err := profile.RemoveCompletion(card.ID, date)
+	if err != nil {
+		fmt.Printf("Error removing completion from card %q: %s\n", path, err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Success! Removed %q completion in %s from %s on card:\n", removed.Type, removed.Duration, removed.Date.Format("2006-01-02 15:04"))
+	color.New(color.Bold).Print(path)
+	fmt.Println("")
+}
+
 func init() {
 	completeCmd.Flags().StringP("path", "p", "", "path to the card")
 	completeCmd.Flags().DurationP("time", "t", time.Duration(0), "time taken to complete the card, in XhYmZs or YmZs format")
+	completeCmd.Flags().Bool("undo", false, "remove a completion from the card instead of adding one")
+	completeCmd.Flags().String("date", "", "with --undo, the date of the completion to remove in '2006-01-02 15:04' format, defaults to the most recent")
+	completeCmd.Flags().String("profile", "", "the profile to add or remove the completion for, defaults to the main one")
 
 	rootCmd.AddCommand(completeCmd)
 }
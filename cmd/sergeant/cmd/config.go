@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/albatross-org/go-albatross/albatross"
+	"github.com/albatross-org/sergeant"
+	"github.com/fatih/color"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// configCmd represents the 'config' command.
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage the config",
+	Long: `Config contains commands for working with the config file.
+
+For more information, see:
+
+	$ sergeant config check --help
+	`,
+}
+
+// configCheckCmd represents the 'config check' command.
+var configCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Check the config for problems",
+	Long: `Check loads the config and reports any problems with it, along with the line and column they're on. As well as
+the problems that stop the config from loading, it finds:
+
+	- Unknown keys, such as 'path' instead of 'paths'
+	- Invalid durations and dates
+	- Sets named 'all', which collide with the built-in set
+	- Paths in sets that don't match any cards
+	- Tags in sets that aren't on any cards
+
+For example:
+
+	$ sergeant config check
+	# Or, to check a different config:
+	$ sergeant config check --config ./config.yaml
+
+If there are any problems, the command exits with a non-zero status.
+	`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		configPath, err := cmd.Flags().GetString("config")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		if configPath == "" {
+			configPath = sergeant.DefaultConfigPath()
+		}
+
+		// The cards are only needed to check paths and tags, so if the config or store can't be loaded the other
+		// checks still go ahead.
+		var cards []*sergeant.Card
+		failed := false
+
+		config, err := sergeant.LoadConfig(configPath)
+		if err != nil {
+			color.New(color.FgRed).Println(err)
+			failed = true
+		} else {
+			cards, err = loadCardsForCheck(config)
+			if err != nil {
+				color.New(color.FgRed).Printf("Couldn't load cards, so paths and tags won't be checked: %s\n", err)
+				failed = true
+			}
+		}
+
+		problems, err := sergeant.CheckConfig(configPath, cards)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		for _, problem := range problems {
+			fmt.Println(problem)
+		}
+
+		if len(problems) > 0 {
+			color.New(color.FgRed).Printf("Found %d problems in %s\n", len(problems), configPath)
+		}
+
+		if failed || len(problems) > 0 {
+			os.Exit(1)
+		}
+
+		color.New(color.FgGreen).Printf("No problems found in %s\n", configPath)
+	},
+}
+
+// loadCardsForCheck returns every card in the store the config points to.
+func loadCardsForCheck(config sergeant.Config) ([]*sergeant.Card, error) {
+	underlyingStore, err := albatross.FromConfig(config.Store)
+	if err != nil {
+		return nil, err
+	}
+
+	cards, _, err := sergeant.NewStore(underlyingStore, config).Cards()
+	if err != nil {
+		return nil, err
+	}
+
+	return cards, nil
+}
+
+func init() {
+	configCmd.AddCommand(configCheckCmd)
+	rootCmd.AddCommand(configCmd)
+}
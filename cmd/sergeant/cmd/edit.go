@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/albatross-org/go-albatross/albatross"
+	"github.com/albatross-org/sergeant"
+	"github.com/fatih/color"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// editCmd represents the 'edit' command.
+var editCmd = &cobra.Command{
+	Use:   "edit --path [path to card] (--move [new category]) (--tags [tags]) (--question [question image]...) (--answer [answer image]...) (--alternative [images])",
+	Short: "Edit a card",
+	Long: `Edit lets you fix a card after it's been added, without having to change the albatross store by hand.
+
+You can move a card that was filed under the wrong chapter. The card keeps its completions:
+
+	$ sergeant edit --path 'further-maths/core-pure-1/chapter-1-complex-numbers/ex1a/question-abcdef' --move 'further-maths/core-pure-1/chapter-2-series/ex2a'
+
+Replace all of its tags:
+
+	$ sergeant edit -p 'further-maths/core-pure-1/chapter-1-complex-numbers/ex1a/question-abcdef' -t @?school -t @?hard
+
+Or replace the question or answer image, such as when the answer was scanned with part of it cropped off:
+
+	$ sergeant edit -p 'further-maths/core-pure-1/chapter-1-complex-numbers/ex1a/question-abcdef' --answer 'answer.png'
+
+Answers that run over several pages are given one page at a time, and another way of solving the question can be added
+as an alternative, with its pages separated by commas:
+
+	$ sergeant edit -p 'further-maths/core-pure-1/chapter-1-complex-numbers/ex1a/question-abcdef' \
+		-a 'answer-page-1.png' -a 'answer-page-2.png' \
+		--alternative 'other-method-1.png,other-method-2.png'
+	`,
+	Args: cobra.NoArgs,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		configPath, err := cmd.Flags().GetString("config")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		config, err := sergeant.LoadConfig(configPath)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		underlyingStore, err := albatross.FromConfig(config.Store)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		store := sergeant.NewStore(underlyingStore, config)
+
+		path, err := cmd.Flags().GetString("path")
+		checkFlag(err, "--path", "edit")
+
+		movePath, err := cmd.Flags().GetString("move")
+		checkFlag(err, "--move", "edit")
+
+		tags, err := cmd.Flags().GetStringSlice("tags")
+		checkFlag(err, "--tags", "edit")
+
+		questionPaths, err := cmd.Flags().GetStringArray("question")
+		checkFlag(err, "--question", "edit")
+
+		answerPaths, err := cmd.Flags().GetStringArray("answer")
+		checkFlag(err, "--answer", "edit")
+
+		alternatives, err := cmd.Flags().GetStringArray("alternative")
+		checkFlag(err, "--alternative", "edit")
+
+		card, err := store.CardByPath(path)
+		if err != nil {
+			fmt.Printf("Error getting card %q: %s\n", path, err)
+			os.Exit(1)
+		}
+
+		if cmd.Flags().Changed("tags") {
+			err = store.SetTags(card.ID, tags)
+			if err != nil {
+				fmt.Printf("Error changing the tags of card %q: %s\n", path, err)
+				os.Exit(1)
+			}
+		}
+
+		if len(questionPaths) != 0 {
+			err = store.ReplaceQuestionImages(card.ID, questionPaths)
+			if err != nil {
+				fmt.Printf("Error replacing the question images of card %q: %s\n", path, err)
+				os.Exit(1)
+			}
+		}
+
+		if len(answerPaths) != 0 {
+			err = store.ReplaceAnswerImages(card.ID, answerPaths)
+			if err != nil {
+				fmt.Printf("Error replacing the answer images of card %q: %s\n", path, err)
+				os.Exit(1)
+			}
+		}
+
+		for _, alternative := range alternatives {
+			err = store.AddAlternative(card.ID, strings.Split(alternative, ","))
+			if err != nil {
+				fmt.Printf("Error adding an alternative solution to card %q: %s\n", path, err)
+				os.Exit(1)
+			}
+		}
+
+		// The card is moved last, since its path changes.
+		if movePath != "" {
+			card, err = store.MoveCard(card.ID, movePath)
+			if err != nil {
+				fmt.Printf("Error moving card %q: %s\n", path, err)
+				os.Exit(1)
+			}
+		}
+
+		fmt.Print("Success! Your card now exists at: ")
+		color.New(color.Bold).Print(card.Path)
+		fmt.Println("")
+	},
+}
+
+func init() {
+	editCmd.Flags().StringP("path", "p", "", "path to the card")
+	editCmd.Flags().StringP("move", "m", "", "path to the category to move the card to")
+	editCmd.Flags().StringSliceP("tags", "t", []string{}, "tags to replace the card's tags with")
+	editCmd.Flags().StringArrayP("question", "q", []string{}, "path to the new question image, given more than once for questions with several pages")
+	editCmd.Flags().StringArrayP("answer", "a", []string{}, "path to the new answer image, given more than once for answers with several pages")
+	editCmd.Flags().StringArray("alternative", []string{}, "comma-separated paths to the pages of an alternative solution to add")
+
+	rootCmd.AddCommand(editCmd)
+}
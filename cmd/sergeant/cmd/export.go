@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/albatross-org/go-albatross/albatross"
+	"github.com/albatross-org/sergeant"
+	"github.com/fatih/color"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// exportCmd represents the 'export' command.
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export cards",
+	Long: `Export lets you take cards out of the program, such as to print them.
+
+For more information, see:
+
+	$ sergeant export pdf --help
+	`,
+}
+
+// exportPDFCmd represents the 'export pdf' command.
+var exportPDFCmd = &cobra.Command{
+	Use:   "pdf --set [set name] --output [path]",
+	Short: "Export a set as a printable worksheet",
+	Long: `Export PDF lays out the question images of every card in a set as a printable worksheet, with the answers in an
+appendix at the end.
+
+For example:
+
+	$ sergeant export pdf --set series-may-2021 --output series.pdf
+	# Or, using the short versions of the flags:
+	$ sergeant export pdf -s series-may-2021 -o series.pdf
+
+If no output is given, the worksheet is saved as '<set name>.pdf' in the current directory.
+	`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		configPath, err := cmd.Flags().GetString("config")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		config, err := sergeant.LoadConfig(configPath)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		underlyingStore, err := albatross.FromConfig(config.Store)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		store := sergeant.NewStore(underlyingStore, config)
+
+		setName, err := cmd.Flags().GetString("set")
+		checkFlag(err, "--set", "export pdf")
+
+		outputPath, err := cmd.Flags().GetString("output")
+		checkFlag(err, "--output", "export pdf")
+
+		if outputPath == "" {
+			outputPath = setName + ".pdf"
+		}
+
+		set, warnings, err := store.Set(setName)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		for path, warning := range warnings {
+			logrus.Warningf("Malformed card: %s -> %s", path, warning)
+		}
+
+		if len(set.Cards) == 0 {
+			logrus.Fatalf("There are no cards in the %q set", setName)
+		}
+
+		file, err := os.Create(outputPath)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		defer file.Close()
+
+		err = sergeant.ExportPDF(file, store.Sets[setName].Name, set.Cards)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		fmt.Printf("Success! Exported %d cards to: ", len(set.Cards))
+		color.New(color.Bold).Print(outputPath)
+		fmt.Println("")
+	},
+}
+
+func init() {
+	exportPDFCmd.Flags().StringP("set", "s", "all", "name of the set to export")
+	exportPDFCmd.Flags().StringP("output", "o", "", "path to save the PDF to")
+
+	exportCmd.AddCommand(exportPDFCmd)
+	rootCmd.AddCommand(exportCmd)
+}
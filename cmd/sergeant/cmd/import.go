@@ -0,0 +1,364 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/albatross-org/go-albatross/albatross"
+	"github.com/albatross-org/sergeant"
+	"github.com/albatross-org/sergeant/anki"
+	"github.com/fatih/color"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// importCmd represents the 'import' command.
+var importCmd = &cobra.Command{
+	Use:   "import [file] --path [path]",
+	Short: "Import cards from Anki or a manifest",
+	Long: `Import lets you add lots of cards at once, either from a deck exported from Anki or from a manifest listing
+the question and answer images for each card.
+
+For example, to import a CSV manifest:
+
+	$ sergeant import questions.csv --path 'further-maths/core-pure-1'
+
+Each row of a CSV manifest is the path, question image, answer image and space-separated tags of a card. Paths are
+relative to the --path given and images are relative to the manifest. A header row is optional:
+
+	path,question,answer,tags
+	chapter-1-complex-numbers/ex1a,images/q1.png,images/a1.png,@?hard
+	chapter-1-complex-numbers/ex1a,images/q2.png,images/a2.png,
+
+A YAML manifest is a list with the same fields:
+
+	- path: chapter-1-complex-numbers/ex1a
+	  question: images/q1.png
+	  answer: images/a1.png
+	  tags: ["@?hard"]
+
+To import an Anki deck, export it from Anki as a .apkg file with "Include media" and "Support older Anki versions"
+ticked:
+
+	$ sergeant import maths.apkg --path 'anki'
+
+Each note becomes a card, with the first image in the front of the note as the question and the first image in the
+back as the answer. Notes without images are skipped, since cards need both. The deck a note is in is added to the
+path, so a note in "Maths::Complex Numbers" ends up in 'anki/maths/complex-numbers', and Anki tags are added as "@?" tags.
+Reviews are kept as completions: "Again" becomes a major, "Hard" a minor, and "Good" or "Easy" a perfect completion.
+	`,
+	Args: cobra.ExactArgs(1),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		configPath, err := cmd.Flags().GetString("config")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		config, err := sergeant.LoadConfig(configPath)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		store, err := albatross.FromConfig(config.Store)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		path, err := cmd.Flags().GetString("path")
+		checkFlag(err, "--path", "import")
+
+		tags, err := cmd.Flags().GetStringSlice("tags")
+		checkFlag(err, "--tags", "import")
+
+		importPath := args[0]
+
+		// The importing is done in importFile so that the files it extracts are cleaned up before exiting on an error.
+		imported, total, err := importFile(store, importPath, path, tags)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		fmt.Print("Success! Imported ")
+		color.New(color.Bold).Printf("%d", imported)
+		fmt.Printf(" of %d cards into: ", total)
+		color.New(color.Bold).Print(filepath.Join(path, "."))
+		fmt.Println("")
+	},
+}
+
+// importFile adds the cards in the file at importPath to the store under path, adding the tags given to each of them.
+// Cards that can't be added are skipped with a warning. It returns how many cards were added out of how many were
+// found in the file.
+func importFile(store *albatross.Store, importPath string, path string, tags []string) (imported int, total int, err error) {
+	var cards []importedCard
+
+	switch strings.ToLower(filepath.Ext(importPath)) {
+	case ".apkg":
+		pkg, err := anki.Open(importPath)
+		if err != nil {
+			return 0, 0, err
+		}
+		defer pkg.Close()
+
+		// The media is extracted to a temporary directory, which has to stay around until the cards have been added.
+		mediaDir, cleanup := tempDir()
+		defer cleanup()
+
+		cards = readAnkiPackage(pkg, mediaDir)
+
+	case ".csv":
+		cards, err = readManifestCSV(importPath)
+		if err != nil {
+			return 0, 0, err
+		}
+
+	case ".yaml", ".yml":
+		cards, err = readManifestYAML(importPath)
+		if err != nil {
+			return 0, 0, err
+		}
+
+	default:
+		return 0, 0, fmt.Errorf("don't know how to import %q, expected a .apkg, .csv or .yaml file", importPath)
+	}
+
+	for _, card := range cards {
+		card.card.Tags = append(card.card.Tags, tags...)
+
+		_, err := createCardFrom(store, card.card, filepath.Join(path, card.path), singlePage(card.questionPath, card.answerPath))
+		if err != nil {
+			logrus.Warningf("Couldn't import %s: %s", card.source, err)
+			continue
+		}
+
+		imported++
+	}
+
+	return imported, len(cards), nil
+}
+
+// importedCard is a card read from a file being imported that hasn't been added yet.
+type importedCard struct {
+	// source describes where the card came from, such as "row 3", for error messages.
+	source string
+
+	path         string
+	questionPath string
+	answerPath   string
+
+	card *sergeant.Card
+}
+
+// readManifestCSV reads the cards in a CSV manifest. Each row is the path, question image, answer image and
+// space-separated tags of a card, and images are relative to the manifest.
+func readManifestCSV(manifestPath string) ([]importedCard, error) {
+	file, err := os.Open(manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't open manifest %q: %w", manifestPath, err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read manifest %q: %w", manifestPath, err)
+	}
+
+	dir := filepath.Dir(manifestPath)
+	cards := []importedCard{}
+
+	for i, record := range records {
+		if i == 0 && len(record) > 0 && strings.EqualFold(record[0], "path") {
+			continue
+		}
+
+		if len(record) < 3 || len(record) > 4 {
+			return nil, fmt.Errorf("row %d of manifest %q has %d columns, expected path, question, answer and optionally tags", i+1, manifestPath, len(record))
+		}
+
+		tags := []string{}
+		if len(record) == 4 {
+			tags = strings.Fields(record[3])
+		}
+
+		cards = append(cards, importedCard{
+			source:       fmt.Sprintf("row %d", i+1),
+			path:         record[0],
+			questionPath: resolvePath(dir, record[1]),
+			answerPath:   resolvePath(dir, record[2]),
+			card:         &sergeant.Card{Date: time.Now(), Tags: tags},
+		})
+	}
+
+	return cards, nil
+}
+
+// readManifestYAML reads the cards in a YAML manifest, which is a list of cards with the same fields as a CSV manifest.
+func readManifestYAML(manifestPath string) ([]importedCard, error) {
+	contentBytes, err := ioutil.ReadFile(manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read manifest %q: %w", manifestPath, err)
+	}
+
+	manifest := []struct {
+		Path     string   `yaml:"path"`
+		Question string   `yaml:"question"`
+		Answer   string   `yaml:"answer"`
+		Tags     []string `yaml:"tags"`
+	}{}
+
+	err = yaml.Unmarshal(contentBytes, &manifest)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't parse manifest %q: %w", manifestPath, err)
+	}
+
+	dir := filepath.Dir(manifestPath)
+	cards := []importedCard{}
+
+	for i, item := range manifest {
+		cards = append(cards, importedCard{
+			source:       fmt.Sprintf("item %d", i+1),
+			path:         item.Path,
+			questionPath: resolvePath(dir, item.Question),
+			answerPath:   resolvePath(dir, item.Answer),
+			card:         &sergeant.Card{Date: time.Now(), Tags: item.Tags},
+		})
+	}
+
+	return cards, nil
+}
+
+// readAnkiPackage turns the notes in an Anki package into cards, extracting their images into mediaDir.
+// Notes that can't be turned into cards are skipped with a warning.
+func readAnkiPackage(pkg *anki.Package, mediaDir string) []importedCard {
+	cards := []importedCard{}
+
+	for _, note := range pkg.Notes {
+		source := fmt.Sprintf("Anki note %d", note.ID)
+
+		if len(note.Fields) < 2 {
+			logrus.Warningf("Skipping %s: it has no back", source)
+			continue
+		}
+
+		questionImages, answerImages := anki.Images(note.Fields[0]), anki.Images(note.Fields[1])
+		if len(questionImages) == 0 || len(answerImages) == 0 {
+			logrus.Warningf("Skipping %s: the front and back both need an image", source)
+			continue
+		}
+
+		// Each note gets its own directory since different notes can have images with the same name.
+		noteDir := filepath.Join(mediaDir, fmt.Sprint(note.ID))
+		err := os.MkdirAll(noteDir, 0755)
+		if err != nil {
+			logrus.Warningf("Skipping %s: %s", source, err)
+			continue
+		}
+
+		questionPath, err := pkg.ExtractMedia(questionImages[0], noteDir)
+		if err != nil {
+			logrus.Warningf("Skipping %s: %s", source, err)
+			continue
+		}
+
+		answerPath, err := pkg.ExtractMedia(answerImages[0], noteDir)
+		if err != nil {
+			logrus.Warningf("Skipping %s: %s", source, err)
+			continue
+		}
+
+		// The question and answer images could have the same name, in which case they'd overwrite each other.
+		if questionPath == answerPath {
+			logrus.Warningf("Skipping %s: the front and back use the same image", source)
+			continue
+		}
+
+		card := &sergeant.Card{
+			Date: note.Created,
+		}
+
+		for _, tag := range note.Tags {
+			card.Tags = append(card.Tags, "@?"+tag)
+		}
+
+		for _, review := range note.Reviews {
+			completion := sergeant.Completion{Date: review.Date, Duration: review.Duration}
+
+			switch review.Ease {
+			case anki.EaseAgain:
+				card.CompletionsMajor = append(card.CompletionsMajor, completion)
+			case anki.EaseHard:
+				card.CompletionsMinor = append(card.CompletionsMinor, completion)
+			default:
+				card.CompletionsPerfect = append(card.CompletionsPerfect, completion)
+			}
+		}
+
+		cards = append(cards, importedCard{
+			source:       source,
+			path:         deckPath(note.Deck),
+			questionPath: questionPath,
+			answerPath:   answerPath,
+			card:         card,
+		})
+	}
+
+	return cards
+}
+
+// deckPath turns the name of an Anki deck like "Maths::Complex Numbers" into a path like "maths/complex-numbers".
+func deckPath(deck string) string {
+	parts := []string{}
+
+	for _, part := range strings.Split(deck, "::") {
+		var slug strings.Builder
+		dash := false
+
+		for _, r := range strings.ToLower(strings.TrimSpace(part)) {
+			switch {
+			case unicode.IsLetter(r) || unicode.IsDigit(r):
+				if dash && slug.Len() > 0 {
+					slug.WriteRune('-')
+				}
+
+				slug.WriteRune(r)
+				dash = false
+			case unicode.IsSpace(r) || r == '-' || r == '_':
+				dash = true
+			}
+		}
+
+		if slug.Len() > 0 {
+			parts = append(parts, slug.String())
+		}
+	}
+
+	return strings.Join(parts, "/")
+}
+
+// resolvePath returns path relative to dir, unless it's already absolute.
+func resolvePath(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(dir, path)
+}
+
+func init() {
+	importCmd.Flags().StringP("path", "p", "", "path the imported cards should go under")
+	importCmd.Flags().StringSliceP("tags", "t", []string{}, "tags to add to every card imported")
+
+	rootCmd.AddCommand(importCmd)
+}
@@ -54,12 +54,42 @@ var rootCmd = &cobra.Command{
 
 		logrus.Infof("Loaded %d cards, %d completd. (%.2f%%)", len(set.Cards), completed, 100*float64(completed)/float64(len(set.Cards)))
 
-		server.Run(store)
+		addr, err := cmd.Flags().GetString("addr")
+		checkFlag(err, "--addr", "sergeant")
+
+		basePath, err := cmd.Flags().GetString("base-path")
+		checkFlag(err, "--base-path", "sergeant")
+
+		uiDir, err := cmd.Flags().GetString("ui-dir")
+		checkFlag(err, "--ui-dir", "sergeant")
+
+		certFile, err := cmd.Flags().GetString("tls-cert")
+		checkFlag(err, "--tls-cert", "sergeant")
+
+		keyFile, err := cmd.Flags().GetString("tls-key")
+		checkFlag(err, "--tls-key", "sergeant")
+
+		err = server.Run(store, server.Options{
+			Addr:     addr,
+			BasePath: basePath,
+			UIDir:    uiDir,
+			CertFile: certFile,
+			KeyFile:  keyFile,
+		})
+		if err != nil {
+			logrus.Fatal(err)
+		}
 	},
 }
 
 func init() {
 	rootCmd.PersistentFlags().String("config", "", "sergeant config, defaults to ~/.config/sergeant/config.yaml")
+
+	rootCmd.Flags().String("addr", ":8080", "address for the server to listen on")
+	rootCmd.Flags().String("base-path", "", "path to serve the web UI and API under, like /sergeant, when behind a reverse proxy")
+	rootCmd.Flags().String("ui-dir", "", "directory to serve the web UI from instead of the one built in, like ./public/build")
+	rootCmd.Flags().String("tls-cert", "", "TLS certificate file, to serve over HTTPS")
+	rootCmd.Flags().String("tls-key", "", "TLS key file, to serve over HTTPS")
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
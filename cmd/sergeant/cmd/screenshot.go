@@ -32,7 +32,7 @@ This command will add different cards to the path 'further-maths/core-pure-1/cha
 You could think of this command as automating the process of:
 
 	- Taking screenshots manually
-	- Stiching together the ones that run over page breaks
+	- Keeping the ones that run over page breaks together as pages of the same card
 	- Running the 'sergeant add' command.
 	
 It allows you to turn minutes per question into seconds.
@@ -93,12 +93,12 @@ Which will be present in every entry.
 			if len(images.answerImages) != 0 {
 				fmt.Print(bold.Sprint("Card("), yellow.Sprint(count), bold.Sprint(")"), " - Creating card\n")
 
-				questionImage, answerImage, err := images.Build()
+				pages, err := images.Build()
 				if err != nil {
 					logrus.Fatal(err)
 				}
 
-				entryPath, err := createCard(store, path, tags, questionImage, answerImage)
+				entryPath, err := createCard(store, path, tags, pages)
 				if err != nil {
 					logrus.Fatal(err)
 				}
@@ -152,12 +152,12 @@ Which will be present in every entry.
 		hook.Register(hook.KeyDown, []string{"d", "shift"}, func(e hook.Event) {
 			fmt.Print(bold.Sprint("Card("), yellow.Sprint(count), bold.Sprint(")"), " - Creating card\n")
 
-			questionImage, answerImage, err := images.Build()
+			pages, err := images.Build()
 			if err != nil {
 				logrus.Fatal(err)
 			}
 
-			entryPath, err := createCard(store, path, tags, questionImage, answerImage)
+			entryPath, err := createCard(store, path, tags, pages)
 			if err != nil {
 				logrus.Fatal(err)
 			}
@@ -187,12 +187,12 @@ Which will be present in every entry.
 			// Save the last flashcard created.
 			if len(images.answerImages)+len(images.questionImages) != 0 {
 				fmt.Print(bold.Sprint("Card("), yellow.Sprint(count), bold.Sprint(")"), " - Creating last card\n")
-				questionImage, answerImage, err := images.Build()
+				pages, err := images.Build()
 				if err != nil {
 					logrus.Fatal(err)
 				}
 
-				entryPath, err := createCard(store, path, tags, questionImage, answerImage)
+				entryPath, err := createCard(store, path, tags, pages)
 				if err != nil {
 					logrus.Fatal(err)
 				}
@@ -208,7 +208,8 @@ Which will be present in every entry.
 	},
 }
 
-// cardImages manages creating a question and answer image from multiple screenshots.
+// cardImages manages creating a question and answer from multiple screenshots. Each screenshot becomes a page of the
+// card.
 type cardImages struct {
 	tempPath string
 	cleanup  func()
@@ -217,9 +218,6 @@ type cardImages struct {
 
 	questionImages []string
 	answerImages   []string
-
-	finalQuestionImage string
-	finalAnswerImage   string
 }
 
 // path returns the path relative to the temporary dir.
@@ -227,44 +225,6 @@ func (c *cardImages) path(path string) string {
 	return filepath.Join(c.tempPath, path)
 }
 
-// generateFinalQuestionImage sets the finalQuestionImage by creating appending the images in questionImages together.
-func (c *cardImages) generateFinalQuestionImage() error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	if len(c.questionImages) == 0 {
-		return fmt.Errorf("there are no questionImages to tile together")
-	}
-
-	dest := c.path("question.png")
-	err := tileImages(dest, c.questionImages)
-	if err != nil {
-		return err
-	}
-
-	c.finalQuestionImage = dest
-	return nil
-}
-
-// generateFinalAnswerImage sets the finalAnswerImage by creating appending the images in answerImages together.
-func (c *cardImages) generateFinalAnswerImage() error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	if len(c.answerImages) == 0 {
-		return fmt.Errorf("there are no answerImages to tile together")
-	}
-
-	dest := c.path("answer.png")
-	err := tileImages(dest, c.answerImages)
-	if err != nil {
-		return err
-	}
-
-	c.finalAnswerImage = dest
-	return nil
-}
-
 // ScanQuestion scans a question in.
 func (c *cardImages) ScanQuestion() error {
 	c.mu.Lock()
@@ -322,42 +282,28 @@ func (c *cardImages) Cancel() error {
 	return nil
 }
 
-// Build returns the final question image and the final answer image and resets all values internally.
-func (c *cardImages) Build() (questionImage string, answerImage string, err error) {
-	err = c.generateFinalQuestionImage()
-	if err != nil {
-		return "", "", err
-	}
+// Build returns the pages of the question and answer that have been scanned so far.
+func (c *cardImages) Build() (cardPages, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	err = c.generateFinalAnswerImage()
-	if err != nil {
-		return "", "", err
+	if len(c.questionImages) == 0 {
+		return cardPages{}, fmt.Errorf("no question has been scanned")
 	}
 
-	questionImage = c.finalQuestionImage
-	answerImage = c.finalAnswerImage
+	if len(c.answerImages) == 0 {
+		return cardPages{}, fmt.Errorf("no answer has been scanned")
+	}
 
-	return questionImage, answerImage, nil
+	return cardPages{
+		questions: append([]string{}, c.questionImages...),
+		answers:   append([]string{}, c.answerImages...),
+	}, nil
 }
 
 // Cleanup deletes all intermediate workings. This should be called after a successful call to .Build().
 func (c *cardImages) Cleanup() error {
-	err := c.Cancel()
-	if err != nil {
-		return err
-	}
-
-	err = os.Remove(c.finalQuestionImage)
-	if err != nil {
-		return err
-	}
-
-	err = os.Remove(c.finalAnswerImage)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return c.Cancel()
 }
 
 // screenshot takes a screenshot using the maim tool.
@@ -374,20 +320,6 @@ func screenshot(dest string) error {
 	return nil
 }
 
-// tileImages takes a list of paths and outputs an image with all those paths tiled together. It relies on having
-// ImageMagic installed.
-func tileImages(destination string, input []string) error {
-	args := append(input, "-append", destination)
-	convertCmd := exec.Command("convert", args...)
-
-	bs, err := convertCmd.Output()
-	if err != nil {
-		return fmt.Errorf("convert command '%s' destination '%s', an error: %w", convertCmd.String(), string(bs), err)
-	}
-
-	return nil
-}
-
 func init() {
 	screenshotCmd.Flags().StringP("path", "p", "", "path to where the card should go")
 	screenshotCmd.Flags().StringSliceP("tags", "t", []string{}, "tags to add to the entry created")
@@ -1,14 +1,18 @@
 package sergeant
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/albatross-org/go-albatross/albatross"
 	"github.com/mitchellh/go-homedir"
+	"github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v3"
 )
 
@@ -22,9 +26,62 @@ var DefaultSetAll = ConfigSet{
 
 // Config represents the top-level configuration for the program.
 type Config struct {
+	// Path is the file the config was loaded from. Sets changed through the Store are written back to it.
+	Path string
+
 	Names ConfigNames
 	Sets  map[string]ConfigSet
 	Store *albatross.Config
+
+	// ReloadInterval is how often the card index checks the store for changes made outside of the program.
+	// If it's zero, DefaultReloadInterval is used.
+	ReloadInterval time.Duration
+
+	// SessionsPath is the directory where study sessions are saved.
+	SessionsPath string
+
+	// ExamsPath is the directory where exams are saved.
+	ExamsPath string
+
+	// ThumbnailsPath is the directory where downscaled copies of card images are cached.
+	ThumbnailsPath string
+
+	// RecentCards and RecentDuration control how long cards are avoided for after being served. If they're zero,
+	// DefaultRecentCards and DefaultRecentDuration are used.
+	RecentCards    int
+	RecentDuration time.Duration
+
+	// Views are the named views defined in the config. If the config has a views section, these replace the
+	// DefaultViews rather than being added to them, so that only the views wanted are offered. It's nil if there
+	// isn't a views section.
+	Views map[string]ConfigView
+
+	// Server configures who can use the web server.
+	Server ConfigServer
+}
+
+// ConfigServer is the configuration for the web server's authentication and CORS.
+type ConfigServer struct {
+	// Password is what has to be given to log in through the web UI. Tokens can be used instead of logging in by
+	// sending them in an "Authorization: Bearer" header, which is useful for scripts.
+	// If neither are set, anyone who can reach the server can use it.
+	Password string
+	Tokens   []string
+
+	// SessionDuration is how long someone stays logged in for. If it's zero, DefaultSessionDuration is used.
+	SessionDuration time.Duration
+
+	// AllowedOrigins are the origins, like "http://localhost:3000", that pages on other sites can use the API from.
+	// An origin of "*" allows every origin, but only origins listed by name can use the API while logged in.
+	AllowedOrigins []string
+}
+
+// DefaultSessionDuration is how long someone stays logged in to the web server if the config doesn't say otherwise.
+const DefaultSessionDuration = 30 * 24 * time.Hour
+
+// AuthEnabled reports whether the server should require logging in to change anything.
+func (server ConfigServer) AuthEnabled() bool {
+	return server.Password != "" || len(server.Tokens) > 0
 }
 
 // ConfigSet represents the definition of a set, as specified in the config file.
@@ -38,13 +95,72 @@ type ConfigSet struct {
 	PathsAnd []string `yaml:"paths-and"`
 	TagsAnd  []string `yaml:"tags-and"`
 
+	// ExcludePaths and ExcludeTags remove cards from the set if they're in any of the paths or have any of the tags.
+	ExcludePaths []string `yaml:"exclude-paths"`
+	ExcludeTags  []string `yaml:"exclude-tags"`
+
+	// Query is a filter query, as understood by ParseFilter, that cards also have to match to be in the set.
+	Query string `yaml:"query"`
+
 	BeforeDuration time.Duration
 	AfterDuration  time.Duration
 	BeforeDate     time.Time
 	AfterDate      time.Time
 
+	// These look at when the card was last completed rather than when it was created.
+	LastAnsweredBeforeDuration time.Duration
+	LastAnsweredAfterDuration  time.Duration
+	LastAnsweredBeforeDate     time.Time
+	LastAnsweredAfterDate      time.Time
+
+	MinMajors      int      `yaml:"min-majors"`
+	LastResult     []string `yaml:"last-result"`
+	MedianTimeOver time.Duration
+	NeverPerfect   bool `yaml:"never-perfect"`
+
 	Color      string
 	Background string
+
+	// rawDurations holds the durations above as they were written, by key, so that they can be written back to the
+	// config the same way rather than as something like "168h0m0s".
+	rawDurations map[string]string
+}
+
+// Validate checks that the options in a ConfigSet make sense, such as the query being valid and durations not being
+// negative.
+func (set ConfigSet) Validate() error {
+	if set.Query != "" {
+		_, err := ParseFilter(set.Query)
+		if err != nil {
+			return fmt.Errorf("couldn't parse query %q in %q set: %w", set.Query, set.Name, err)
+		}
+	}
+
+	if set.MinMajors < 0 {
+		return fmt.Errorf("min-majors in %q set can't be negative, got %d", set.Name, set.MinMajors)
+	}
+
+	for _, completionType := range set.LastResult {
+		if completionType != "perfect" && completionType != "minor" && completionType != "major" {
+			return fmt.Errorf("invalid last-result %q in %q set: please use 'perfect', 'minor' or 'major'", completionType, set.Name)
+		}
+	}
+
+	durations := map[string]time.Duration{
+		"before-duration":               set.BeforeDuration,
+		"after-duration":                set.AfterDuration,
+		"last-answered-before-duration": set.LastAnsweredBeforeDuration,
+		"last-answered-after-duration":  set.LastAnsweredAfterDuration,
+		"median-time-over":              set.MedianTimeOver,
+	}
+
+	for field, duration := range durations {
+		if duration < 0 {
+			return fmt.Errorf("%s in %q set can't be negative, got %s", field, set.Name, duration)
+		}
+	}
+
+	return nil
 }
 
 // AsFilter returns a ConfigSet as a filter that allows cards only if they're supposed to be in that set.
@@ -67,6 +183,16 @@ func (set ConfigSet) AsFilter() Filter {
 		filters = append(filters, FilterPaths(tagsAnd))
 	}
 
+	if len(set.ExcludePaths) > 0 {
+		filters = append(filters, FilterNOT(FilterPaths(set.ExcludePaths...)))
+	}
+
+	// FilterTags only matches cards with all of the tags given, so each tag is checked on its own to exclude cards
+	// with any of them.
+	for _, excludeTag := range set.ExcludeTags {
+		filters = append(filters, FilterNOT(FilterTags(excludeTag)))
+	}
+
 	if set.BeforeDate != (time.Time{}) {
 		filters = append(filters, FilterBeforeDate(set.BeforeDate))
 	}
@@ -83,24 +209,187 @@ func (set ConfigSet) AsFilter() Filter {
 		filters = append(filters, FilterAfterDuration(set.AfterDuration))
 	}
 
+	if set.LastAnsweredBeforeDate != (time.Time{}) {
+		filters = append(filters, FilterLastAnsweredBeforeDate(set.LastAnsweredBeforeDate))
+	}
+
+	if set.LastAnsweredAfterDate != (time.Time{}) {
+		filters = append(filters, FilterLastAnsweredAfterDate(set.LastAnsweredAfterDate))
+	}
+
+	if set.LastAnsweredBeforeDuration != time.Duration(0) {
+		filters = append(filters, FilterLastAnsweredBeforeDuration(set.LastAnsweredBeforeDuration))
+	}
+
+	if set.LastAnsweredAfterDuration != time.Duration(0) {
+		filters = append(filters, FilterLastAnsweredAfterDuration(set.LastAnsweredAfterDuration))
+	}
+
+	if set.MinMajors > 0 {
+		filters = append(filters, FilterMinMajors(set.MinMajors))
+	}
+
+	if len(set.LastResult) > 0 {
+		filters = append(filters, FilterLastResult(set.LastResult...))
+	}
+
+	if set.MedianTimeOver != time.Duration(0) {
+		filters = append(filters, FilterMedianTimeOver(set.MedianTimeOver))
+	}
+
+	if set.NeverPerfect {
+		filters = append(filters, FilterNeverPerfect())
+	}
+
+	if set.Query != "" {
+		// The query should have already been checked when the set was created, so this shouldn't happen. If it does,
+		// it's safer to show no cards than to show every card.
+		queryFilter, err := ParseFilter(set.Query)
+		if err != nil {
+			logrus.Errorf("Invalid query %q in set %q: %s", set.Query, set.Name, err)
+			queryFilter = func(card *Card) bool { return false }
+		}
+
+		filters = append(filters, queryFilter)
+	}
+
 	return FilterAND(filters...)
 }
 
+// ConfigView represents the definition of a named view, as specified in the config file. Type is the kind of view,
+// like "difficulties", and the other fields tune it. Fields left as zero use the view's usual values, and only the
+// fields that apply to the type can be set.
+type ConfigView struct {
+	Type string
+
+	// These apply to the "difficulties" and "interleaved" views.
+	TopPercent               float64
+	Power                    float64
+	BaseProbability          float64
+	AssumedSampleProbability float64
+
+	// Ratios applies to the "interleaved" view, and maps topics to the number of cards in a row to serve from them.
+	Ratios map[string]int
+
+	// These apply to the "spaced" view.
+	InitialEase    float64
+	MinimumEase    float64
+	FirstInterval  time.Duration
+	SecondInterval time.Duration
+}
+
+// AsView returns a new instance of the view described by a ConfigView.
+func (configView ConfigView) AsView() View {
+	seed := time.Now().Unix()
+
+	switch configView.Type {
+	case "random":
+		return NewViewRandom(seed)
+	case "unseen":
+		return NewViewUnseen(seed)
+	case "bayesian":
+		return NewViewBayesian(seed)
+
+	case "difficulties":
+		view := NewViewDifficulties(seed)
+		configView.tuneDifficulties(view)
+
+		return view
+
+	case "interleaved":
+		view := NewViewInterleaved(seed, configView.Ratios)
+		configView.tuneDifficulties(view.difficulties)
+
+		return view
+
+	case "spaced":
+		view := NewViewSpaced()
+
+		if configView.InitialEase != 0 {
+			view.initialEase = configView.InitialEase
+		}
+
+		if configView.MinimumEase != 0 {
+			view.minimumEase = configView.MinimumEase
+		}
+
+		if configView.FirstInterval != 0 {
+			view.firstInterval = configView.FirstInterval
+		}
+
+		if configView.SecondInterval != 0 {
+			view.secondInterval = configView.SecondInterval
+		}
+
+		return view
+	}
+
+	if view, ok := registeredView(configView.Type); ok {
+		return view
+	}
+
+	// The type is checked when the config is loaded, so this shouldn't happen.
+	logrus.Errorf("Unknown view type %q", configView.Type)
+	return NewViewRandom(seed)
+}
+
+// tuneDifficulties sets the parameters of a Difficulties view from the config, leaving any that weren't given alone.
+func (configView ConfigView) tuneDifficulties(view *Difficulties) {
+	if configView.TopPercent != 0 {
+		view.topPercent = configView.TopPercent
+	}
+
+	if configView.Power != 0 {
+		view.power = configView.Power
+	}
+
+	if configView.BaseProbability != 0 {
+		view.baseProbability = configView.BaseProbability
+	}
+
+	if configView.AssumedSampleProbability != 0 {
+		view.assumedSampleProbability = configView.AssumedSampleProbability
+	}
+}
+
 // ConfigNames lets you give friendlier names to paths to specific questions.
 type ConfigNames map[string]string
 
 // rawConfigDef is the config definition before additional processing is done on it.
 // This is needed to allow the program to parse the fields instead of using YAML's default unmarshaler.
 type rawConfigDef struct {
-	Sets map[string]rawConfigSetDef `yaml:"sets"`
+	Sets  map[string]rawConfigSetDef  `yaml:"sets"`
+	Views map[string]rawConfigViewDef `yaml:"views"`
 
 	Store *albatross.Config `yaml:"store"`
+
+	ReloadInterval string `yaml:"reload-interval"`
+	SessionsPath   string `yaml:"sessions-path"`
+	ExamsPath      string `yaml:"exams-path"`
+	ThumbnailsPath string `yaml:"thumbnails-path"`
+	RecentCards    int    `yaml:"recent-cards"`
+	RecentDuration string `yaml:"recent-duration"`
+
+	Server rawConfigServerDef `yaml:"server"`
+}
+
+// rawConfigServerDef is the definition of the server's options before additional processing is done on it.
+type rawConfigServerDef struct {
+	Password        string   `yaml:"password"`
+	Tokens          []string `yaml:"tokens"`
+	SessionDuration string   `yaml:"session-duration"`
+	AllowedOrigins  []string `yaml:"allowed-origins"`
+}
+
+// DefaultConfigPath returns the path of the config used when no other path is given, ".config/sergeant/config.yaml".
+func DefaultConfigPath() string {
+	return filepath.Join(getConfigDir(), "sergeant", "config.yaml")
 }
 
 // LoadConfig returns the Config located at the given path. If no path is specified, the default ".config/sergeant/config.yaml" is used.
 func LoadConfig(path string) (Config, error) {
 	if path == "" {
-		path = filepath.Join(getConfigDir(), "sergeant", "config.yaml")
+		path = DefaultConfigPath()
 	}
 
 	contentBytes, err := ioutil.ReadFile(path)
@@ -116,6 +405,7 @@ func LoadConfig(path string) (Config, error) {
 	}
 
 	config := Config{
+		Path: path,
 		Sets: make(map[string]ConfigSet),
 	}
 
@@ -129,8 +419,74 @@ func LoadConfig(path string) (Config, error) {
 	}
 
 	config.Sets["all"] = DefaultSetAll
+
+	// An empty views section still replaces the DefaultViews, which leaves only registered views.
+	if rawConfig.Views != nil {
+		config.Views = make(map[string]ConfigView)
+	}
+
+	for name, rawConfigView := range rawConfig.Views {
+		configView, err := parseRawConfigViewDef(name, rawConfigView)
+		if err != nil {
+			return Config{}, err
+		}
+
+		config.Views[name] = configView
+	}
 	config.Store = rawConfig.Store
 
+	if rawConfig.ReloadInterval != "" {
+		config.ReloadInterval, err = time.ParseDuration(rawConfig.ReloadInterval)
+		if err != nil {
+			return Config{}, fmt.Errorf("couldn't parse reload-interval %q: %w", rawConfig.ReloadInterval, err)
+		}
+	}
+
+	if rawConfig.RecentCards < 0 {
+		return Config{}, fmt.Errorf("recent-cards can't be negative, got %d", rawConfig.RecentCards)
+	}
+
+	config.RecentCards = rawConfig.RecentCards
+
+	if rawConfig.RecentDuration != "" {
+		config.RecentDuration, err = parseDuration(rawConfig.RecentDuration)
+		if err != nil {
+			return Config{}, fmt.Errorf("couldn't parse recent-duration %q: %w", rawConfig.RecentDuration, err)
+		}
+	}
+
+	config.SessionsPath, err = homedir.Expand(rawConfig.SessionsPath)
+	if err != nil {
+		return Config{}, fmt.Errorf("couldn't expand sessions-path %q: %w", rawConfig.SessionsPath, err)
+	}
+
+	if config.SessionsPath == "" {
+		config.SessionsPath = filepath.Join(getDataDir(), "sergeant", "sessions")
+	}
+
+	config.ExamsPath, err = homedir.Expand(rawConfig.ExamsPath)
+	if err != nil {
+		return Config{}, fmt.Errorf("couldn't expand exams-path %q: %w", rawConfig.ExamsPath, err)
+	}
+
+	if config.ExamsPath == "" {
+		config.ExamsPath = filepath.Join(getDataDir(), "sergeant", "exams")
+	}
+
+	config.ThumbnailsPath, err = homedir.Expand(rawConfig.ThumbnailsPath)
+	if err != nil {
+		return Config{}, fmt.Errorf("couldn't expand thumbnails-path %q: %w", rawConfig.ThumbnailsPath, err)
+	}
+
+	if config.ThumbnailsPath == "" {
+		config.ThumbnailsPath = filepath.Join(getCacheDir(), "sergeant", "thumbnails")
+	}
+
+	config.Server, err = parseRawConfigServerDef(rawConfig.Server)
+	if err != nil {
+		return Config{}, err
+	}
+
 	setStoreDefaults(config.Store)
 
 	return config, nil
@@ -165,24 +521,67 @@ func setStoreDefaults(c *albatross.Config) {
 	}
 }
 
+// parseRawConfigServerDef turns a rawConfigServerDef into a ConfigServer.
+func parseRawConfigServerDef(rawConfigServer rawConfigServerDef) (ConfigServer, error) {
+	server := ConfigServer{
+		Password:        rawConfigServer.Password,
+		Tokens:          rawConfigServer.Tokens,
+		AllowedOrigins:  rawConfigServer.AllowedOrigins,
+		SessionDuration: DefaultSessionDuration,
+	}
+
+	for _, token := range server.Tokens {
+		if token == "" {
+			return ConfigServer{}, fmt.Errorf("tokens in server can't be blank")
+		}
+	}
+
+	if rawConfigServer.SessionDuration != "" {
+		duration, err := parseDuration(rawConfigServer.SessionDuration)
+		if err != nil {
+			return ConfigServer{}, fmt.Errorf("couldn't parse session-duration %q in server: %w", rawConfigServer.SessionDuration, err)
+		}
+
+		server.SessionDuration = duration
+	}
+
+	return server, nil
+}
+
 // rawConfigSetDef is a definition of a set before additional processing is done on it.
-// This is needed to allow the program to parse the fields such as BeforeDuration.
+// This is needed to allow the program to parse the fields such as BeforeDuration. Fields are left out when they're
+// empty so that sets written back to the config only contain what was set.
 type rawConfigSetDef struct {
-	Name        string `yaml:"name"`
-	Description string `yaml:"description"`
+	Name        string `yaml:"name,omitempty"`
+	Description string `yaml:"description,omitempty"`
 
-	PathsOr  []string `yaml:"paths"`
-	TagsOr   []string `yaml:"tags"`
-	PathsAnd []string `yaml:"paths-and"`
-	TagsAnd  []string `yaml:"tags-and"`
+	PathsOr  []string `yaml:"paths,omitempty"`
+	TagsOr   []string `yaml:"tags,omitempty"`
+	PathsAnd []string `yaml:"paths-and,omitempty"`
+	TagsAnd  []string `yaml:"tags-and,omitempty"`
+
+	ExcludePaths []string `yaml:"exclude-paths,omitempty"`
+	ExcludeTags  []string `yaml:"exclude-tags,omitempty"`
+
+	Query string `yaml:"query,omitempty"`
+
+	BeforeDuration string `yaml:"before-duration,omitempty"`
+	AfterDuration  string `yaml:"after-duration,omitempty"`
+	BeforeDate     string `yaml:"before-date,omitempty"`
+	AfterDate      string `yaml:"after-date,omitempty"`
 
-	BeforeDuration string `yaml:"before-duration"`
-	AfterDuration  string `yaml:"after-duration"`
-	BeforeDate     string `yaml:"before-date"`
-	AfterDate      string `yaml:"after-date"`
+	LastAnsweredBeforeDuration string `yaml:"last-answered-before-duration,omitempty"`
+	LastAnsweredAfterDuration  string `yaml:"last-answered-after-duration,omitempty"`
+	LastAnsweredBeforeDate     string `yaml:"last-answered-before-date,omitempty"`
+	LastAnsweredAfterDate      string `yaml:"last-answered-after-date,omitempty"`
 
-	Color      string `yaml:"color"`
-	Background string `yaml:"background"`
+	MinMajors      int      `yaml:"min-majors,omitempty"`
+	LastResult     []string `yaml:"last-result,omitempty"`
+	MedianTimeOver string   `yaml:"median-time-over,omitempty"`
+	NeverPerfect   bool     `yaml:"never-perfect,omitempty"`
+
+	Color      string `yaml:"color,omitempty"`
+	Background string `yaml:"background,omitempty"`
 }
 
 // parseRawConfigSetDef turns a rawConfigSetDef into a ConfigSet.
@@ -195,7 +594,10 @@ func parseRawConfigSetDef(rawConfigSet rawConfigSetDef) (ConfigSet, error) {
 	set.PathsOr = rawConfigSet.PathsOr
 	set.TagsAnd = rawConfigSet.TagsAnd
 	set.TagsOr = rawConfigSet.TagsOr
+	set.ExcludePaths = rawConfigSet.ExcludePaths
+	set.ExcludeTags = rawConfigSet.ExcludeTags
 
+	set.Query = rawConfigSet.Query
 	set.Color = rawConfigSet.Color
 	set.Background = rawConfigSet.Background
 
@@ -220,22 +622,302 @@ func parseRawConfigSetDef(rawConfigSet rawConfigSetDef) (ConfigSet, error) {
 	}
 
 	if rawConfigSet.BeforeDuration != "" {
-		set.BeforeDuration, err = time.ParseDuration(rawConfigSet.BeforeDuration)
+		set.BeforeDuration, err = parseDuration(rawConfigSet.BeforeDuration)
 		if err != nil {
 			return ConfigSet{}, fmt.Errorf("couldn't parse before-duration %q in %q set: %w", rawConfigSet.BeforeDuration, rawConfigSet.Name, err)
 		}
 	}
 
 	if rawConfigSet.AfterDuration != "" {
-		set.AfterDuration, err = time.ParseDuration(rawConfigSet.AfterDuration)
+		set.AfterDuration, err = parseDuration(rawConfigSet.AfterDuration)
 		if err != nil {
 			return ConfigSet{}, fmt.Errorf("couldn't parse after-duration %q in %q set: %w", rawConfigSet.AfterDuration, rawConfigSet.Name, err)
 		}
 	}
 
+	if rawConfigSet.LastAnsweredBeforeDate != "" {
+		set.LastAnsweredBeforeDate, err = time.Parse("2006-01-02 15:04", rawConfigSet.LastAnsweredBeforeDate)
+		if err != nil {
+			return ConfigSet{}, fmt.Errorf("couldn't parse last-answered-before-date %q in %q set: %w", rawConfigSet.LastAnsweredBeforeDate, rawConfigSet.Name, err)
+		}
+	}
+
+	if rawConfigSet.LastAnsweredAfterDate != "" {
+		set.LastAnsweredAfterDate, err = time.Parse("2006-01-02 15:04", rawConfigSet.LastAnsweredAfterDate)
+		if err != nil {
+			return ConfigSet{}, fmt.Errorf("couldn't parse last-answered-after-date %q in %q set: %w", rawConfigSet.LastAnsweredAfterDate, rawConfigSet.Name, err)
+		}
+	}
+
+	if rawConfigSet.LastAnsweredBeforeDuration != "" {
+		set.LastAnsweredBeforeDuration, err = parseDuration(rawConfigSet.LastAnsweredBeforeDuration)
+		if err != nil {
+			return ConfigSet{}, fmt.Errorf("couldn't parse last-answered-before-duration %q in %q set: %w", rawConfigSet.LastAnsweredBeforeDuration, rawConfigSet.Name, err)
+		}
+	}
+
+	if rawConfigSet.LastAnsweredAfterDuration != "" {
+		set.LastAnsweredAfterDuration, err = parseDuration(rawConfigSet.LastAnsweredAfterDuration)
+		if err != nil {
+			return ConfigSet{}, fmt.Errorf("couldn't parse last-answered-after-duration %q in %q set: %w", rawConfigSet.LastAnsweredAfterDuration, rawConfigSet.Name, err)
+		}
+	}
+
+	set.MinMajors = rawConfigSet.MinMajors
+	set.LastResult = rawConfigSet.LastResult
+
+	if rawConfigSet.MedianTimeOver != "" {
+		set.MedianTimeOver, err = parseDuration(rawConfigSet.MedianTimeOver)
+		if err != nil {
+			return ConfigSet{}, fmt.Errorf("couldn't parse median-time-over %q in %q set: %w", rawConfigSet.MedianTimeOver, rawConfigSet.Name, err)
+		}
+	}
+
+	set.NeverPerfect = rawConfigSet.NeverPerfect
+
+	for key, rawDuration := range map[string]string{
+		"before-duration":               rawConfigSet.BeforeDuration,
+		"after-duration":                rawConfigSet.AfterDuration,
+		"last-answered-before-duration": rawConfigSet.LastAnsweredBeforeDuration,
+		"last-answered-after-duration":  rawConfigSet.LastAnsweredAfterDuration,
+		"median-time-over":              rawConfigSet.MedianTimeOver,
+	} {
+		if rawDuration == "" {
+			continue
+		}
+
+		if set.rawDurations == nil {
+			set.rawDurations = map[string]string{}
+		}
+
+		set.rawDurations[key] = rawDuration
+	}
+
+	err = set.Validate()
+	if err != nil {
+		return ConfigSet{}, err
+	}
+
 	return set, nil
 }
 
+// ParseConfigSet parses the definition of a set, written in the same way as a set in the config file. Since JSON is
+// a subset of YAML, the definition can also be JSON, which lets sets be created through the API.
+func ParseConfigSet(data []byte) (ConfigSet, error) {
+	rawConfigSet := rawConfigSetDef{}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	err := decoder.Decode(&rawConfigSet)
+	if err != nil && err != io.EOF {
+		return ConfigSet{}, fmt.Errorf("couldn't unmarshal set: %w", err)
+	}
+
+	return parseRawConfigSetDef(rawConfigSet)
+}
+
+// configSetToRaw turns a ConfigSet back into the rawConfigSetDef it could have been parsed from, so that it can be
+// written to the config file.
+func configSetToRaw(set ConfigSet) rawConfigSetDef {
+	rawConfigSet := rawConfigSetDef{
+		Name:         set.Name,
+		PathsOr:      set.PathsOr,
+		TagsOr:       set.TagsOr,
+		PathsAnd:     set.PathsAnd,
+		TagsAnd:      set.TagsAnd,
+		ExcludePaths: set.ExcludePaths,
+		ExcludeTags:  set.ExcludeTags,
+		Query:        set.Query,
+		MinMajors:    set.MinMajors,
+		LastResult:   set.LastResult,
+		NeverPerfect: set.NeverPerfect,
+		Color:        set.Color,
+		Background:   set.Background,
+	}
+
+	// The default description is filled in when the set is parsed, so there's no need to write it out.
+	if set.Description != "This is a custom set." {
+		rawConfigSet.Description = set.Description
+	}
+
+	formatDate := func(date time.Time) string {
+		if date.IsZero() {
+			return ""
+		}
+
+		return date.Format("2006-01-02 15:04")
+	}
+
+	// Durations are written as they were given if they haven't changed since.
+	formatSetDuration := func(key string, duration time.Duration) string {
+		if duration == 0 {
+			return ""
+		}
+
+		if raw, ok := set.rawDurations[key]; ok {
+			if parsed, err := parseDuration(raw); err == nil && parsed == duration {
+				return raw
+			}
+		}
+
+		return formatDuration(duration)
+	}
+
+	rawConfigSet.BeforeDate = formatDate(set.BeforeDate)
+	rawConfigSet.AfterDate = formatDate(set.AfterDate)
+	rawConfigSet.LastAnsweredBeforeDate = formatDate(set.LastAnsweredBeforeDate)
+	rawConfigSet.LastAnsweredAfterDate = formatDate(set.LastAnsweredAfterDate)
+
+	rawConfigSet.BeforeDuration = formatSetDuration("before-duration", set.BeforeDuration)
+	rawConfigSet.AfterDuration = formatSetDuration("after-duration", set.AfterDuration)
+	rawConfigSet.LastAnsweredBeforeDuration = formatSetDuration("last-answered-before-duration", set.LastAnsweredBeforeDuration)
+	rawConfigSet.LastAnsweredAfterDuration = formatSetDuration("last-answered-after-duration", set.LastAnsweredAfterDuration)
+	rawConfigSet.MedianTimeOver = formatSetDuration("median-time-over", set.MedianTimeOver)
+
+	return rawConfigSet
+}
+
+// yamlIndent returns the number of spaces a YAML file is indented by, so that it can be written back the same way. It's
+// taken from the first indented line, and is 4 if there aren't any.
+func yamlIndent(content []byte) int {
+	for _, line := range strings.Split(string(content), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		// The encoder can only indent by between 2 and 9 spaces.
+		if indent := len(line) - len(trimmed); indent >= 2 && indent <= 9 {
+			return indent
+		}
+	}
+
+	return 4
+}
+
+// writeConfigSet changes the definition of a single set in the sets section of the config file at the given path,
+// adding it if it isn't there already, or removes it if set is nil. The file is edited as a YAML node tree rather
+// than being marshalled again from a Config so that everything else in it, including comments, is kept.
+func writeConfigSet(path string, name string, set *rawConfigSetDef) error {
+	contentBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("couldn't read config located at %q: %w", path, err)
+	}
+
+	doc := yaml.Node{}
+
+	err = yaml.Unmarshal(contentBytes, &doc)
+	if err != nil {
+		return fmt.Errorf("couldn't unmarshal config located at %q: %w", path, err)
+	}
+
+	// An empty file doesn't have a document node at all.
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("config located at %q isn't a mapping", path)
+	}
+
+	var sets *yaml.Node
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "sets" {
+			sets = root.Content[i+1]
+			break
+		}
+	}
+
+	if sets == nil {
+		if set == nil {
+			return nil
+		}
+
+		sets = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "sets"}, sets)
+	}
+
+	// A "sets:" key with nothing after it is null rather than an empty mapping.
+	if sets.Kind == yaml.ScalarNode && sets.Tag == "!!null" {
+		*sets = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	}
+
+	if sets.Kind != yaml.MappingNode {
+		return fmt.Errorf("sets in config located at %q isn't a mapping", path)
+	}
+
+	index := -1
+
+	for i := 0; i+1 < len(sets.Content); i += 2 {
+		if sets.Content[i].Value == name {
+			index = i
+			break
+		}
+	}
+
+	switch {
+	case set == nil && index != -1:
+		sets.Content = append(sets.Content[:index], sets.Content[index+2:]...)
+
+	case set != nil:
+		value := &yaml.Node{}
+
+		err = value.Encode(set)
+		if err != nil {
+			return fmt.Errorf("couldn't marshal %q set: %w", name, err)
+		}
+
+		if index != -1 {
+			// Keep any comments attached to the old definition.
+			value.HeadComment = sets.Content[index+1].HeadComment
+			value.LineComment = sets.Content[index+1].LineComment
+			value.FootComment = sets.Content[index+1].FootComment
+			sets.Content[index+1] = value
+		} else {
+			sets.Content = append(sets.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}, value)
+		}
+	}
+
+	var buf bytes.Buffer
+
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(yamlIndent(contentBytes))
+
+	err = encoder.Encode(&doc)
+	if err != nil {
+		return fmt.Errorf("couldn't marshal config located at %q: %w", path, err)
+	}
+
+	err = encoder.Close()
+	if err != nil {
+		return fmt.Errorf("couldn't marshal config located at %q: %w", path, err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("couldn't stat config located at %q: %w", path, err)
+	}
+
+	// The new config is written to a temporary file first so that a failed write can't leave it half-written.
+	tmp := path + ".tmp"
+
+	err = ioutil.WriteFile(tmp, buf.Bytes(), info.Mode())
+	if err != nil {
+		return fmt.Errorf("couldn't write config located at %q: %w", path, err)
+	}
+
+	err = os.Rename(tmp, path)
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("couldn't write config located at %q: %w", path, err)
+	}
+
+	return nil
+}
+
 // getConfigDir gets the user's configuration directory.
 // TODO: At the moment, this uses $XDG_CONFIG_HOME and falls back to
 // $HOME/.config which isn't cross platform.
@@ -252,3 +934,171 @@ func getConfigDir() string {
 
 	return filepath.Join(home, ".config")
 }
+
+// getDataDir gets the user's data directory.
+// Like getConfigDir, this uses $XDG_DATA_HOME and falls back to $HOME/.local/share which isn't cross platform.
+func getDataDir() string {
+	data := os.Getenv("XDG_DATA_HOME")
+	if data != "" {
+		return data
+	}
+
+	home, err := homedir.Dir()
+	if err != nil {
+		panic(err) // This really shouldn't happen.
+	}
+
+	return filepath.Join(home, ".local", "share")
+}
+
+// getCacheDir gets the user's cache directory.
+// Like getConfigDir, this uses $XDG_CACHE_HOME and falls back to $HOME/.cache which isn't cross platform.
+func getCacheDir() string {
+	cache := os.Getenv("XDG_CACHE_HOME")
+	if cache != "" {
+		return cache
+	}
+
+	home, err := homedir.Dir()
+	if err != nil {
+		panic(err) // This really shouldn't happen.
+	}
+
+	return filepath.Join(home, ".cache")
+}
+
+// rawConfigViewDef is the definition of a view before additional processing is done on it.
+// Parameters are pointers so that it's possible to tell whether they were given for a view they don't apply to.
+type rawConfigViewDef struct {
+	Type string `yaml:"type"`
+
+	TopPercent               *float64 `yaml:"top-percent"`
+	Power                    *float64 `yaml:"power"`
+	BaseProbability          *float64 `yaml:"base-probability"`
+	AssumedSampleProbability *float64 `yaml:"assumed-sample-probability"`
+
+	InitialEase    *float64 `yaml:"initial-ease"`
+	MinimumEase    *float64 `yaml:"minimum-ease"`
+	FirstInterval  *string  `yaml:"first-interval"`
+	SecondInterval *string  `yaml:"second-interval"`
+
+	Ratios map[string]int `yaml:"ratios"`
+}
+
+// parseRawConfigViewDef turns a rawConfigViewDef into a ConfigView, checking that the type exists and that the
+// parameters given make sense for it.
+func parseRawConfigViewDef(name string, rawConfigView rawConfigViewDef) (ConfigView, error) {
+	configView := ConfigView{Type: rawConfigView.Type}
+
+	difficultiesParams := rawConfigView.TopPercent != nil || rawConfigView.Power != nil || rawConfigView.BaseProbability != nil || rawConfigView.AssumedSampleProbability != nil
+	spacedParams := rawConfigView.InitialEase != nil || rawConfigView.MinimumEase != nil || rawConfigView.FirstInterval != nil || rawConfigView.SecondInterval != nil
+
+	ratioParams := rawConfigView.Ratios != nil
+
+	switch rawConfigView.Type {
+	case "random", "unseen", "bayesian":
+		if difficultiesParams || spacedParams || ratioParams {
+			return ConfigView{}, fmt.Errorf("the %q view has parameters, but views of type %q don't take any", name, rawConfigView.Type)
+		}
+
+	case "difficulties", "interleaved":
+		if spacedParams {
+			return ConfigView{}, fmt.Errorf("the %q view has parameters that only apply to spaced views", name)
+		}
+
+		if ratioParams && rawConfigView.Type != "interleaved" {
+			return ConfigView{}, fmt.Errorf("the %q view has ratios, but they only apply to interleaved views", name)
+		}
+
+		for topic, ratio := range rawConfigView.Ratios {
+			if ratio < 1 {
+				return ConfigView{}, fmt.Errorf("ratio for %q in %q view must be at least 1, got %d", topic, name, ratio)
+			}
+		}
+
+		configView.Ratios = rawConfigView.Ratios
+
+		if rawConfigView.TopPercent != nil {
+			if *rawConfigView.TopPercent <= 0 || *rawConfigView.TopPercent > 1 {
+				return ConfigView{}, fmt.Errorf("top-percent in %q view must be more than 0 and at most 1, got %v", name, *rawConfigView.TopPercent)
+			}
+
+			configView.TopPercent = *rawConfigView.TopPercent
+		}
+
+		if rawConfigView.Power != nil {
+			if *rawConfigView.Power <= 0 {
+				return ConfigView{}, fmt.Errorf("power in %q view must be more than 0, got %v", name, *rawConfigView.Power)
+			}
+
+			configView.Power = *rawConfigView.Power
+		}
+
+		if rawConfigView.BaseProbability != nil {
+			if *rawConfigView.BaseProbability <= 0 || *rawConfigView.BaseProbability > 1 {
+				return ConfigView{}, fmt.Errorf("base-probability in %q view must be more than 0 and at most 1, got %v", name, *rawConfigView.BaseProbability)
+			}
+
+			configView.BaseProbability = *rawConfigView.BaseProbability
+		}
+
+		if rawConfigView.AssumedSampleProbability != nil {
+			if *rawConfigView.AssumedSampleProbability <= 0 || *rawConfigView.AssumedSampleProbability > 1 {
+				return ConfigView{}, fmt.Errorf("assumed-sample-probability in %q view must be more than 0 and at most 1, got %v", name, *rawConfigView.AssumedSampleProbability)
+			}
+
+			configView.AssumedSampleProbability = *rawConfigView.AssumedSampleProbability
+		}
+
+	case "spaced":
+		if difficultiesParams || ratioParams {
+			return ConfigView{}, fmt.Errorf("the %q view has parameters that only apply to difficulties or interleaved views", name)
+		}
+
+		if rawConfigView.InitialEase != nil {
+			if *rawConfigView.InitialEase < 1 {
+				return ConfigView{}, fmt.Errorf("initial-ease in %q view must be at least 1, got %v", name, *rawConfigView.InitialEase)
+			}
+
+			configView.InitialEase = *rawConfigView.InitialEase
+		}
+
+		if rawConfigView.MinimumEase != nil {
+			if *rawConfigView.MinimumEase < 1 {
+				return ConfigView{}, fmt.Errorf("minimum-ease in %q view must be at least 1, got %v", name, *rawConfigView.MinimumEase)
+			}
+
+			configView.MinimumEase = *rawConfigView.MinimumEase
+		}
+
+		var err error
+
+		if rawConfigView.FirstInterval != nil {
+			configView.FirstInterval, err = parseDuration(*rawConfigView.FirstInterval)
+			if err != nil || configView.FirstInterval <= 0 {
+				return ConfigView{}, fmt.Errorf("couldn't parse first-interval %q in %q view: expected a positive duration like 1d", *rawConfigView.FirstInterval, name)
+			}
+		}
+
+		if rawConfigView.SecondInterval != nil {
+			configView.SecondInterval, err = parseDuration(*rawConfigView.SecondInterval)
+			if err != nil || configView.SecondInterval <= 0 {
+				return ConfigView{}, fmt.Errorf("couldn't parse second-interval %q in %q view: expected a positive duration like 6d", *rawConfigView.SecondInterval, name)
+			}
+		}
+
+	case "":
+		return ConfigView{}, fmt.Errorf("the %q view doesn't have a type", name)
+
+	default:
+		if !viewRegistered(rawConfigView.Type) {
+			return ConfigView{}, fmt.Errorf("the %q view has unknown type %q: please use 'random', 'unseen', 'difficulties', 'bayesian', 'spaced', 'interleaved' or a registered view", name, rawConfigView.Type)
+		}
+
+		if difficultiesParams || spacedParams || ratioParams {
+			return ConfigView{}, fmt.Errorf("the %q view has parameters, but registered views of type %q can't be given any", name, rawConfigView.Type)
+		}
+	}
+
+	return configView, nil
+}
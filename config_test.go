@@ -0,0 +1,205 @@
+package sergeant
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+// TestParseRawConfigViewDef tests that named views in the config are parsed and tuned with the parameters given.
+func TestParseRawConfigViewDef(t *testing.T) {
+	rawViews := map[string]rawConfigViewDef{}
+	err := yaml.Unmarshal([]byte(`
+hard-focus:
+  type: difficulties
+  top-percent: 0.2
+  power: 3
+  base-probability: 0.4
+  assumed-sample-probability: 0.8
+slow-spaced:
+  type: spaced
+  first-interval: 2d
+plain:
+  type: random
+mixed:
+  type: interleaved
+  power: 3
+  ratios:
+    maths: 2
+`), &rawViews)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	hardFocus, err := parseRawConfigViewDef("hard-focus", rawViews["hard-focus"])
+	if assert.NoError(t, err, "not expecting error parsing difficulties view") {
+		view := hardFocus.AsView().(*Difficulties)
+		assert.Equal(t, 0.2, view.topPercent)
+		assert.Equal(t, 3.0, view.power)
+		assert.Equal(t, 0.4, view.baseProbability)
+		assert.Equal(t, 0.8, view.assumedSampleProbability)
+	}
+
+	slowSpaced, err := parseRawConfigViewDef("slow-spaced", rawViews["slow-spaced"])
+	if assert.NoError(t, err, "not expecting error parsing spaced view") {
+		view := slowSpaced.AsView().(*Spaced)
+		assert.Equal(t, 48*time.Hour, view.firstInterval)
+		assert.Equal(t, NewViewSpaced().secondInterval, view.secondInterval, "expected parameters not given to be left alone")
+	}
+
+	plain, err := parseRawConfigViewDef("plain", rawViews["plain"])
+	if assert.NoError(t, err, "not expecting error parsing random view") {
+		assert.IsType(t, &Random{}, plain.AsView())
+	}
+
+	mixed, err := parseRawConfigViewDef("mixed", rawViews["mixed"])
+	if assert.NoError(t, err, "not expecting error parsing interleaved view") {
+		view := mixed.AsView().(*Interleaved)
+		assert.Equal(t, 3.0, view.difficulties.power)
+		assert.Equal(t, 2, view.ratio("maths"))
+		assert.Equal(t, 1, view.ratio("physics"), "expected topics without a ratio to default to 1")
+	}
+
+	topPercent, power, assumedSampleProbability := 1.5, 2.0, 0.0
+
+	invalid := map[string]rawConfigViewDef{
+		"NoType":       {},
+		"UnknownType":  {Type: "sideways"},
+		"OutOfRange":   {Type: "difficulties", TopPercent: &topPercent},
+		"NoAssumed":    {Type: "difficulties", AssumedSampleProbability: &assumedSampleProbability},
+		"WrongType":    {Type: "spaced", Power: &power},
+		"NoParameters": {Type: "unseen", Power: &power},
+		"BadInterval":  {Type: "spaced", FirstInterval: new(string)},
+		"WrongRatios":  {Type: "difficulties", Ratios: map[string]int{"maths": 2}},
+		"BadRatio":     {Type: "interleaved", Ratios: map[string]int{"maths": 0}},
+	}
+
+	for name, rawView := range invalid {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseRawConfigViewDef(name, rawView)
+			assert.Error(t, err, "expected error parsing invalid view")
+		})
+	}
+}
+
+// TestParseRawConfigServerDef tests that the server's options are parsed and that authentication is only turned on
+// when a password or tokens are given.
+func TestParseRawConfigServerDef(t *testing.T) {
+	rawServer := rawConfigServerDef{}
+	err := yaml.Unmarshal([]byte(`
+password: hunter2
+session-duration: 1w
+allowed-origins:
+  - http://localhost:3000
+`), &rawServer)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	server, err := parseRawConfigServerDef(rawServer)
+	if assert.NoError(t, err) {
+		assert.True(t, server.AuthEnabled())
+		assert.Equal(t, 7*24*time.Hour, server.SessionDuration)
+		assert.Equal(t, []string{"http://localhost:3000"}, server.AllowedOrigins)
+	}
+
+	server, err = parseRawConfigServerDef(rawConfigServerDef{})
+	if assert.NoError(t, err) {
+		assert.False(t, server.AuthEnabled(), "expected authentication to be off without a password or tokens")
+		assert.Equal(t, DefaultSessionDuration, server.SessionDuration)
+	}
+
+	_, err = parseRawConfigServerDef(rawConfigServerDef{Tokens: []string{""}})
+	assert.Error(t, err, "expected error for blank token")
+
+	_, err = parseRawConfigServerDef(rawConfigServerDef{SessionDuration: "forever"})
+	assert.Error(t, err, "expected error for invalid session-duration")
+}
+
+// TestWriteConfigSet tests that sets written back to the config are added, replaced and removed without losing the
+// rest of the file.
+func TestWriteConfigSet(t *testing.T) {
+	file, err := ioutil.TempFile("", "sergeant-config-*.yaml")
+	if err != nil {
+		t.Fatalf("couldn't create temporary file: %s", err)
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.WriteString(`# How often to check for changes.
+reload-interval: 1m
+store: {}
+
+sets:
+    # Only the hard questions.
+    hard:
+        name: Hard
+        tags:
+            - '@?hard'
+    old:
+        name: Old
+`)
+	file.Close()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	set, err := ParseConfigSet([]byte(`{"paths": ["maths/series"], "last-answered-before-duration": "1w", "min-majors": 2}`))
+	if !assert.NoError(t, err, "not expecting error parsing set") {
+		return
+	}
+
+	raw := configSetToRaw(set)
+	assert.NoError(t, writeConfigSet(file.Name(), "series", &raw), "not expecting error adding set")
+	assert.NoError(t, writeConfigSet(file.Name(), "old", nil), "not expecting error removing set")
+
+	config, err := LoadConfig(file.Name())
+	if !assert.NoError(t, err, "not expecting error loading written config") {
+		return
+	}
+
+	assert.Equal(t, time.Minute, config.ReloadInterval, "expected the rest of the config to be kept")
+	assert.Equal(t, []string{"@?hard"}, config.Sets["hard"].TagsOr, "expected other sets to be kept")
+	assert.NotContains(t, config.Sets, "old", "expected removed set to be gone")
+	assert.Equal(t, set, config.Sets["series"], "expected added set to be the same after loading")
+
+	contentBytes, err := ioutil.ReadFile(file.Name())
+	if assert.NoError(t, err) {
+		assert.Contains(t, string(contentBytes), "# Only the hard questions.", "expected comments to be kept")
+		assert.Contains(t, string(contentBytes), "\n    hard:\n        name: Hard\n", "expected indentation to be kept")
+		assert.Contains(t, string(contentBytes), "last-answered-before-duration: 1w\n", "expected duration to be written as it was given")
+	}
+
+	err = ioutil.WriteFile(file.Name(), []byte("sets:\n  hard:\n    name: Hard\n"), 0644)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.NoError(t, writeConfigSet(file.Name(), "series", &raw), "not expecting error adding set")
+
+	contentBytes, err = ioutil.ReadFile(file.Name())
+	if assert.NoError(t, err) {
+		assert.Contains(t, string(contentBytes), "\n  series:\n    paths:\n", "expected indentation of two spaces to be kept")
+	}
+
+	_, err = ParseConfigSet([]byte(`{"path": ["maths/series"]}`))
+	assert.Error(t, err, "expected error for unknown field")
+
+	_, err = ParseConfigSet([]byte(`{"last-result": ["wrong"]}`))
+	assert.Error(t, err, "expected error for invalid last-result")
+}
+
+// TestParseConfigSetDurations tests that every duration in a set can be given in days and weeks.
+func TestParseConfigSetDurations(t *testing.T) {
+	set, err := ParseConfigSet([]byte(`{"before-duration": "90d", "after-duration": "2w", "median-time-over": "0.5d"}`))
+	if !assert.NoError(t, err, "not expecting error parsing set") {
+		return
+	}
+
+	assert.Equal(t, 90*24*time.Hour, set.BeforeDuration)
+	assert.Equal(t, 14*24*time.Hour, set.AfterDuration)
+	assert.Equal(t, 12*time.Hour, set.MedianTimeOver)
+}
@@ -0,0 +1,527 @@
+package sergeant
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Exam is a timed attempt at a fixed paper of cards, like sitting a past paper.
+// Unlike a Session, the cards are all picked when the exam starts and are shown in order, and answers aren't added to
+// the cards as completions until the whole exam is submitted.
+type Exam struct {
+	ID string `json:"id"`
+
+	SetName  string    `json:"setName"`
+	Set      ConfigSet `json:"set"`
+	ViewName string    `json:"viewName"`
+
+	// Profile is the name of the profile sitting the exam, whose completions are used and added to.
+	Profile string `json:"profile,omitempty"`
+
+	// TimeLimit is the total time allowed for the whole paper. If it's zero, there is no time limit.
+	TimeLimit time.Duration `json:"timeLimit"`
+
+	Started   time.Time `json:"started"`
+	Submitted time.Time `json:"submitted,omitempty"`
+
+	// Current is the index of the question currently being shown. Once every question has been answered, it's equal
+	// to the number of questions.
+	Current int `json:"current"`
+
+	// CurrentServed is when the current question was first shown.
+	CurrentServed time.Time `json:"currentServed,omitempty"`
+
+	// Questions is the paper, in the order the questions are shown. Each one holds the answer given to it, if any.
+	Questions []ExamQuestion `json:"questions"`
+}
+
+// ExamQuestion is a single card on an exam paper and the answer given to it.
+type ExamQuestion struct {
+	CardID string `json:"cardID"`
+	Path   string `json:"path"`
+
+	// Answer is either a completion type ("perfect", "minor" or "major"), "skip" if the question was skipped or the
+	// empty string if it was never reached.
+	Answer   string        `json:"answer,omitempty"`
+	Date     time.Time     `json:"date,omitempty"`
+	Duration time.Duration `json:"duration,omitempty"`
+
+	// Recorded is whether the answer has been added to the card as a completion.
+	Recorded bool `json:"recorded,omitempty"`
+
+	// Deleted is whether the card had been deleted by the time the exam was submitted, so the answer couldn't be added
+	// to it. The answer still counts towards the exam's report.
+	Deleted bool `json:"deleted,omitempty"`
+}
+
+// ExamReport summarises how an exam went.
+type ExamReport struct {
+	Questions  int
+	Perfect    int
+	Minor      int
+	Major      int
+	Skipped    int
+	Unanswered int
+
+	// Score is the fraction of questions on the paper that were answered perfectly.
+	Score float64
+
+	// Elapsed is the total time spent on the questions that were answered or skipped.
+	Elapsed   time.Duration
+	TimeLimit time.Duration
+
+	// OverTime is whether the exam was finished after the time limit ran out. It goes by when the exam was started and
+	// finished on the server rather than the durations given with the answers, so it can't be avoided by the client.
+	OverTime bool
+
+	// Topics breaks the results down by the category each card is in, in alphabetical order.
+	Topics []ExamTopicReport
+}
+
+// ExamTopicReport holds the results of the questions on an exam paper from a single category.
+type ExamTopicReport struct {
+	Path       string
+	Questions  int
+	Perfect    int
+	Minor      int
+	Major      int
+	Skipped    int
+	Unanswered int
+}
+
+// Deadline returns the time the exam has to be finished by. If there's no time limit, it returns the zero time.
+func (exam *Exam) Deadline() time.Time {
+	if exam.TimeLimit == 0 {
+		return time.Time{}
+	}
+
+	return exam.Started.Add(exam.TimeLimit)
+}
+
+// Remaining returns how much time is left until the deadline, which will be negative once it has passed. If there's
+// no time limit, it returns zero.
+func (exam *Exam) Remaining(now time.Time) time.Duration {
+	if exam.TimeLimit == 0 {
+		return 0
+	}
+
+	return exam.Deadline().Sub(now)
+}
+
+// Expired reports whether the time limit has run out.
+func (exam *Exam) Expired(now time.Time) bool {
+	return exam.TimeLimit != 0 && !now.Before(exam.Deadline())
+}
+
+// Finished reports whether the exam has been submitted.
+func (exam *Exam) Finished() bool {
+	return !exam.Submitted.IsZero()
+}
+
+// finished returns when the exam was finished, which is when the last question was answered if every one was, or
+// otherwise when it was submitted. It returns the zero time if the exam is still going.
+func (exam *Exam) finished() time.Time {
+	if len(exam.Questions) > 0 && exam.Current >= len(exam.Questions) {
+		return exam.Questions[len(exam.Questions)-1].Date
+	}
+
+	return exam.Submitted
+}
+
+// Report summarises the answers given so far.
+func (exam *Exam) Report() ExamReport {
+	report := ExamReport{
+		Questions: len(exam.Questions),
+		TimeLimit: exam.TimeLimit,
+		Topics:    []ExamTopicReport{},
+	}
+
+	topics := map[string]*ExamTopicReport{}
+
+	for _, question := range exam.Questions {
+		path := question.Path
+		if i := strings.LastIndex(path, "/"); i != -1 {
+			path = path[:i]
+		}
+
+		topic := topics[path]
+		if topic == nil {
+			topic = &ExamTopicReport{Path: path}
+			topics[path] = topic
+		}
+
+		topic.Questions++
+		report.Elapsed += question.Duration
+
+		switch question.Answer {
+		case "perfect":
+			report.Perfect++
+			topic.Perfect++
+		case "minor":
+			report.Minor++
+			topic.Minor++
+		case "major":
+			report.Major++
+			topic.Major++
+		case "skip":
+			report.Skipped++
+			topic.Skipped++
+		default:
+			report.Unanswered++
+			topic.Unanswered++
+		}
+	}
+
+	if report.Questions > 0 {
+		report.Score = float64(report.Perfect) / float64(report.Questions)
+	}
+
+	finished := exam.finished()
+	report.OverTime = exam.TimeLimit != 0 && !finished.IsZero() && finished.Sub(exam.Started) > exam.TimeLimit
+
+	for _, topic := range topics {
+		report.Topics = append(report.Topics, *topic)
+	}
+
+	sort.Slice(report.Topics, func(i, j int) bool {
+		return report.Topics[i].Path < report.Topics[j].Path
+	})
+
+	return report
+}
+
+// clone returns a copy of the exam that can be read safely while the original continues to be modified.
+func (exam *Exam) clone() *Exam {
+	clone := *exam
+	clone.Questions = append([]ExamQuestion{}, exam.Questions...)
+
+	return &clone
+}
+
+// recordID returns the ID of the exam, so that it can be kept in a jsonDirStore.
+func (exam *Exam) recordID() string {
+	return exam.ID
+}
+
+// recordStarted returns when the exam was started, so that it can be kept in a jsonDirStore.
+func (exam *Exam) recordStarted() time.Time {
+	return exam.Started
+}
+
+// examStore keeps track of exams and saves each one to its own JSON file in a directory whenever it changes.
+type examStore struct {
+	*jsonDirStore
+}
+
+// newExamStore returns a new examStore that saves exams in the directory given.
+func newExamStore(path string) *examStore {
+	return &examStore{newJSONDirStore(path, "exam", func() jsonRecord { return &Exam{} })}
+}
+
+// get returns the exam with the given ID, loading it from disk if it hasn't been seen yet.
+// The caller must hold the lock.
+func (exams *examStore) get(id string) (*Exam, error) {
+	record, err := exams.jsonDirStore.get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return record.(*Exam), nil
+}
+
+// save writes an exam to disk. The caller must hold the lock.
+func (exams *examStore) save(exam *Exam) error {
+	return exams.jsonDirStore.save(exam)
+}
+
+// list returns every saved exam, most recently started first. The caller must hold the lock.
+func (exams *examStore) list() ([]*Exam, error) {
+	records, err := exams.jsonDirStore.list()
+	if err != nil {
+		return nil, err
+	}
+
+	list := []*Exam{}
+	for _, record := range records {
+		list = append(list, record.(*Exam))
+	}
+
+	return list, nil
+}
+
+// buildPaper picks up to n different cards from a set using a view, in the order the view picks them.
+func buildPaper(view View, set *Set, n int, now time.Time) []*Card {
+	return pickCards(view, set, n, ViewContext{Now: now})
+}
+
+// pickCards asks a view for up to n different cards from a set in a row, giving it the context each time.
+func pickCards(view View, set *Set, n int, ctx ViewContext) []*Card {
+	paper := []*Card{}
+	picked := map[string]bool{}
+
+	for len(paper) < n {
+		remaining := &Set{}
+		for _, card := range set.Cards {
+			if !picked[card.ID] {
+				remaining.Cards = append(remaining.Cards, card)
+			}
+		}
+
+		if len(remaining.Cards) == 0 {
+			break
+		}
+
+		card := NextCard(view, remaining, ctx)
+		if card == nil {
+			break
+		}
+
+		picked[card.ID] = true
+		paper = append(paper, card)
+	}
+
+	return paper
+}
+
+// StartExam starts a new exam with a paper of up to n cards picked from the set given by the view with the name given,
+// which has to be finished within the time limit. If the time limit is zero, the exam isn't timed.
+// If there are fewer than n cards in the set, the paper contains all of them.
+func (store *Store) StartExam(setName string, set ConfigSet, viewName string, n int, timeLimit time.Duration) (*Exam, error) {
+	return store.Profile(DefaultProfile).StartExam(setName, set, viewName, n, timeLimit)
+}
+
+// StartExam starts a new exam for the profile, like (*Store).StartExam.
+func (profile *Profile) StartExam(setName string, set ConfigSet, viewName string, n int, timeLimit time.Duration) (*Exam, error) {
+	store := profile.store
+
+	if n <= 0 {
+		return nil, fmt.Errorf("an exam needs at least one question, got %d", n)
+	}
+
+	if timeLimit < 0 {
+		return nil, fmt.Errorf("the time limit can't be negative, got %s", timeLimit)
+	}
+
+	view := store.Views[viewName]
+	if view == nil {
+		return nil, fmt.Errorf("the view %q doesn't exist", viewName)
+	}
+
+	cards, _, err := profile.SetFromConfig(set)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	paper := buildPaper(view, cards, n, now)
+	if len(paper) == 0 {
+		return nil, fmt.Errorf("couldn't pick any cards from the %q set", setName)
+	}
+
+	exam := &Exam{
+		ID:            newSessionID(),
+		SetName:       setName,
+		Set:           set,
+		ViewName:      viewName,
+		Profile:       profile.Name,
+		TimeLimit:     timeLimit,
+		Started:       now,
+		CurrentServed: now,
+		Questions:     []ExamQuestion{},
+	}
+
+	for _, card := range paper {
+		exam.Questions = append(exam.Questions, ExamQuestion{CardID: card.ID, Path: card.Path})
+	}
+
+	store.exams.mu.Lock()
+	defer store.exams.mu.Unlock()
+
+	err = store.exams.save(exam)
+	if err != nil {
+		return nil, err
+	}
+
+	return exam.clone(), nil
+}
+
+// Exam returns the exam with the given ID.
+func (store *Store) Exam(id string) (*Exam, error) {
+	store.exams.mu.Lock()
+	defer store.exams.mu.Unlock()
+
+	exam, err := store.exams.get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return exam.clone(), nil
+}
+
+// Exams returns every exam, both submitted and unsubmitted, most recently started first.
+func (store *Store) Exams() ([]*Exam, error) {
+	store.exams.mu.Lock()
+	defer store.exams.mu.Unlock()
+
+	list, err := store.exams.list()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, exam := range list {
+		list[i] = exam.clone()
+	}
+
+	return list, nil
+}
+
+// ExamNext returns the card for the question currently being shown in an exam. It returns a nil card once every
+// question has been answered or the time limit has run out, at which point the exam should be submitted.
+func (store *Store) ExamNext(id string) (*Exam, *Card, error) {
+	store.exams.mu.Lock()
+	defer store.exams.mu.Unlock()
+
+	exam, err := store.exams.get(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if exam.Finished() || exam.Expired(time.Now()) || exam.Current >= len(exam.Questions) {
+		return exam.clone(), nil, nil
+	}
+
+	card, err := store.CardByID(exam.Questions[exam.Current].CardID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("couldn't get question %d of exam %q: %w", exam.Current+1, id, err)
+	}
+
+	return exam.clone(), card, nil
+}
+
+// ExamAnswer records the answer for the question currently being shown in an exam and moves on to the next one.
+// The answer should be "perfect", "minor", "major" or "skip". Nothing is added to the card until the exam is submitted.
+// If the duration is zero, the time since the question was first shown is used.
+func (store *Store) ExamAnswer(id string, answer string, duration time.Duration) (*Exam, error) {
+	if answer != "perfect" && answer != "minor" && answer != "major" && answer != "skip" {
+		return nil, fmt.Errorf("invalid answer %q: please use 'perfect', 'minor', 'major' or 'skip'", answer)
+	}
+
+	store.exams.mu.Lock()
+	defer store.exams.mu.Unlock()
+
+	exam, err := store.exams.get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	if exam.Finished() {
+		return nil, fmt.Errorf("exam %q has already been submitted", id)
+	}
+
+	if exam.Expired(now) {
+		return nil, fmt.Errorf("the time limit for exam %q has run out", id)
+	}
+
+	if exam.Current >= len(exam.Questions) {
+		return nil, fmt.Errorf("every question in exam %q has already been answered", id)
+	}
+
+	if duration == 0 {
+		duration = now.Sub(exam.CurrentServed)
+	}
+
+	question := &exam.Questions[exam.Current]
+	question.Answer = answer
+	question.Date = now
+	question.Duration = duration
+
+	exam.Current++
+	exam.CurrentServed = now
+
+	err = store.exams.save(exam)
+	if err != nil {
+		return nil, err
+	}
+
+	return exam.clone(), nil
+}
+
+// SubmitExam finishes an exam and adds every answer given, other than skips, to the cards as completions at once.
+// Questions that were never reached are left out, as are ones whose card has been deleted since the exam started. If
+// adding a completion fails, the exam isn't submitted and can be submitted again later without adding the completions
+// that succeeded twice.
+func (store *Store) SubmitExam(id string) (*Exam, error) {
+	store.exams.mu.Lock()
+	defer store.exams.mu.Unlock()
+
+	exam, err := store.exams.get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if exam.Finished() {
+		return exam.clone(), nil
+	}
+
+	profile := store.Profile(exam.Profile)
+
+	err = exam.recordAnswers(func(question ExamQuestion) error {
+		// The card is looked up again in case it has been moved since the exam started.
+		card, err := store.CardByID(question.CardID)
+		if err != nil {
+			return err
+		}
+
+		return profile.AddCompletion(card.Path, question.Answer, Completion{Date: question.Date, Duration: question.Duration})
+	})
+	if err != nil {
+		// Save which completions were added so that they aren't added again if the exam is submitted again.
+		if saveErr := store.exams.save(exam); saveErr != nil {
+			return nil, saveErr
+		}
+
+		return nil, err
+	}
+
+	exam.Submitted = time.Now()
+	exam.CurrentServed = time.Time{}
+
+	err = store.exams.save(exam)
+	if err != nil {
+		return nil, err
+	}
+
+	return exam.clone(), nil
+}
+
+// recordAnswers passes every answer given in the exam that hasn't been recorded yet, other than skips, to record and
+// marks it as recorded. If record returns ErrNoCard, the question is marked as deleted instead, so that a card being
+// deleted doesn't stop the exam from ever being submitted. It stops at the first other error.
+func (exam *Exam) recordAnswers(record func(question ExamQuestion) error) error {
+	for i := range exam.Questions {
+		question := &exam.Questions[i]
+		if question.Recorded || question.Deleted || question.Answer == "" || question.Answer == "skip" {
+			continue
+		}
+
+		err := record(*question)
+		if errors.Is(err, ErrNoCard) {
+			question.Deleted = true
+			continue
+		}
+
+		if err != nil {
+			return fmt.Errorf("couldn't record answer to question %d of exam %q: %w", i+1, exam.ID, err)
+		}
+
+		question.Recorded = true
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package sergeant
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBuildPaper tests that papers contain different cards and stop early when the set runs out.
+func TestBuildPaper(t *testing.T) {
+	set := seededTestSet()
+	now := time.Date(2021, 02, 16, 10, 18, 0, 0, time.UTC)
+
+	paper := buildPaper(NewViewRandom(0), set, 10, now)
+	assert.Len(t, paper, 10, "expected a paper of the length asked for")
+
+	seen := map[string]bool{}
+	for _, card := range paper {
+		assert.False(t, seen[card.ID], "expected card %q to only be on the paper once", card.ID)
+		seen[card.ID] = true
+	}
+
+	paper = buildPaper(NewViewRandom(0), set, 100, now)
+	assert.Len(t, paper, len(set.Cards), "expected the paper to contain every card when the set is too small")
+}
+
+// TestExamReport tests that exam reports count answers overall and by category.
+func TestExamReport(t *testing.T) {
+	started := time.Date(2021, 02, 16, 10, 18, 0, 0, time.UTC)
+
+	exam := &Exam{
+		TimeLimit: 10 * time.Minute,
+		Started:   started,
+		Submitted: started.Add(12 * time.Minute),
+		Current:   3,
+		Questions: []ExamQuestion{
+			{CardID: "a", Path: "maths/algebra/question-1", Answer: "perfect", Duration: 4 * time.Minute},
+			{CardID: "b", Path: "maths/algebra/question-2", Answer: "major", Duration: 5 * time.Minute},
+			{CardID: "c", Path: "physics/waves/question-1", Answer: "skip", Duration: 2 * time.Minute},
+			{CardID: "d", Path: "physics/waves/question-2"},
+		},
+	}
+
+	report := exam.Report()
+	assert.Equal(t, 4, report.Questions)
+	assert.Equal(t, 1, report.Perfect)
+	assert.Equal(t, 1, report.Major)
+	assert.Equal(t, 1, report.Skipped)
+	assert.Equal(t, 1, report.Unanswered)
+	assert.Equal(t, 0.25, report.Score)
+	assert.Equal(t, 11*time.Minute, report.Elapsed)
+	assert.True(t, report.OverTime, "expected exam submitted after the time limit to be over time")
+
+	exam.Submitted = started.Add(8 * time.Minute)
+	assert.False(t, exam.Report().OverTime, "expected exam submitted in time not to be over time, whatever durations were given")
+
+	assert.Equal(t, []ExamTopicReport{
+		{Path: "maths/algebra", Questions: 2, Perfect: 1, Major: 1},
+		{Path: "physics/waves", Questions: 2, Skipped: 1, Unanswered: 1},
+	}, report.Topics)
+
+	assert.False(t, exam.Expired(started.Add(9*time.Minute)), "expected exam not to have expired")
+	assert.True(t, exam.Expired(started.Add(10*time.Minute)), "expected exam to have expired")
+}
+
+// TestExamRecordAnswers tests that answers are only recorded once, and that a deleted card doesn't stop the rest of
+// the answers from being recorded.
+func TestExamRecordAnswers(t *testing.T) {
+	exam := &Exam{
+		ID: "exam",
+		Questions: []ExamQuestion{
+			{CardID: "a", Answer: "perfect"},
+			{CardID: "deleted", Answer: "major"},
+			{CardID: "c", Answer: "skip"},
+			{CardID: "d"},
+			{CardID: "e", Answer: "minor"},
+		},
+	}
+
+	recorded := []string{}
+	failing := "e"
+
+	record := func(question ExamQuestion) error {
+		switch question.CardID {
+		case "deleted":
+			return fmt.Errorf("card with ID %q not found: %w", question.CardID, ErrNoCard)
+		case failing:
+			return errors.New("disk full")
+		}
+
+		recorded = append(recorded, question.CardID)
+		return nil
+	}
+
+	err := exam.recordAnswers(record)
+	assert.EqualError(t, err, `couldn't record answer to question 5 of exam "exam": disk full`)
+	assert.Equal(t, []string{"a"}, recorded, "expected answers before the failure to be recorded")
+	assert.True(t, exam.Questions[1].Deleted, "expected the question whose card was deleted to be marked as deleted")
+
+	failing = ""
+
+	err = exam.recordAnswers(record)
+	assert.NoError(t, err, "expected the exam to be recorded once the failure has gone away")
+	assert.Equal(t, []string{"a", "e"}, recorded, "expected answers to only be recorded once")
+	assert.False(t, exam.Questions[1].Recorded, "expected the answer to a deleted card not to be recorded")
+	assert.Equal(t, 1, exam.Report().Major, "expected the answer to a deleted card to still count in the report")
+}
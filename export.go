@@ -0,0 +1,150 @@
+package sergeant
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/albatross-org/sergeant/pdf"
+)
+
+// These control the layout of exported worksheets. All sizes are in points.
+const (
+	worksheetMargin = 50.0
+
+	// worksheetPixelSize is how big a pixel of a question image is printed if the image is small enough to fit on the
+	// page without shrinking it. This is the same as viewing the image at 100% on a normal screen.
+	worksheetPixelSize = 0.75
+
+	worksheetLabelHeight = 18.0
+	worksheetSpacing     = 24.0
+)
+
+// ExportPDF writes a printable worksheet of the cards given. The question images are laid out in order of path, each
+// numbered, and the answer images follow in an appendix with the same numbers.
+func ExportPDF(w io.Writer, title string, cards []*Card) error {
+	sorted := append([]*Card{}, cards...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Path < sorted[j].Path
+	})
+
+	sheet := &worksheet{doc: pdf.New(pdf.A4Width, pdf.A4Height), y: worksheetMargin}
+
+	sheet.heading(title)
+	sheet.doc.Text(pdf.FontRegular, 10, worksheetMargin, sheet.y, fmt.Sprintf("%d questions, exported %s", len(sorted), time.Now().Format("2006-01-02")))
+	sheet.y += worksheetSpacing
+
+	for i, card := range sorted {
+		err := sheet.placePages(i+1, card.Path, card.QuestionPaths)
+		if err != nil {
+			return fmt.Errorf("couldn't load question image for card %q: %w", card.Path, err)
+		}
+	}
+
+	sheet.doc.AddPage()
+	sheet.y = worksheetMargin
+	sheet.heading("Answers")
+
+	for i, card := range sorted {
+		err := sheet.placePages(i+1, card.Path, card.AnswerPaths)
+		if err != nil {
+			return fmt.Errorf("couldn't load answer image for card %q: %w", card.Path, err)
+		}
+	}
+
+	err := sheet.doc.Write(w)
+	if err != nil {
+		return fmt.Errorf("couldn't write PDF: %w", err)
+	}
+
+	return nil
+}
+
+// worksheet keeps track of where the next thing should go while a worksheet is being laid out.
+type worksheet struct {
+	doc *pdf.Document
+	y   float64
+}
+
+// heading writes a large heading at the current position.
+func (sheet *worksheet) heading(text string) {
+	sheet.y += 20
+	sheet.doc.Text(pdf.FontBold, 20, worksheetMargin, sheet.y, text)
+	sheet.y += worksheetLabelHeight
+}
+
+// placePages adds the pages of a question or answer, each numbered the same. Pages that can't be drawn, like SVGs and
+// PDFs, are mentioned by name instead so that it's clear something is missing.
+func (sheet *worksheet) placePages(number int, path string, pages []string) error {
+	for _, page := range pages {
+		img, err := pdf.LoadImage(page)
+		if errors.Is(err, image.ErrFormat) {
+			sheet.note(number, path, fmt.Sprintf("%s can't be included in a worksheet.", filepath.Base(page)))
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		sheet.place(number, path, img)
+	}
+
+	return nil
+}
+
+// note adds a numbered line of text, starting a new page if there isn't room for it on the current one.
+func (sheet *worksheet) note(number int, path string, text string) {
+	if sheet.y+2*worksheetLabelHeight > sheet.doc.Height()-worksheetMargin {
+		sheet.doc.AddPage()
+		sheet.y = worksheetMargin
+	}
+
+	sheet.label(number, path)
+
+	sheet.doc.Text(pdf.FontRegular, 10, worksheetMargin, sheet.y+10, text)
+	sheet.y += worksheetLabelHeight + worksheetSpacing
+}
+
+// place adds a numbered image, starting a new page if there isn't room for it on the current one.
+func (sheet *worksheet) place(number int, path string, img *pdf.Image) {
+	contentWidth := sheet.doc.Width() - 2*worksheetMargin
+	contentHeight := sheet.doc.Height() - 2*worksheetMargin - worksheetLabelHeight
+
+	width := float64(img.Width) * worksheetPixelSize
+	height := float64(img.Height) * worksheetPixelSize
+
+	if width > contentWidth {
+		height *= contentWidth / width
+		width = contentWidth
+	}
+
+	if height > contentHeight {
+		width *= contentHeight / height
+		height = contentHeight
+	}
+
+	if sheet.y+worksheetLabelHeight+height > sheet.doc.Height()-worksheetMargin {
+		sheet.doc.AddPage()
+		sheet.y = worksheetMargin
+	}
+
+	sheet.label(number, path)
+
+	sheet.doc.Image(img, worksheetMargin, sheet.y, width, height)
+	sheet.y += height + worksheetSpacing
+}
+
+// label writes the number and path of a card at the current position.
+func (sheet *worksheet) label(number int, path string) {
+	// Long paths are cut short from the start, since the end of the path is the most specific part.
+	if len(path) > 80 {
+		path = "..." + path[len(path)-77:]
+	}
+
+	sheet.doc.Text(pdf.FontBold, 11, worksheetMargin, sheet.y+11, fmt.Sprintf("%d.", number))
+	sheet.doc.Text(pdf.FontRegular, 9, worksheetMargin+28, sheet.y+11, path)
+	sheet.y += worksheetLabelHeight
+}
@@ -1,8 +1,12 @@
 package sergeant
 
 import (
+	"fmt"
+	"path"
+	"strconv"
 	"strings"
 	"time"
+	"unicode"
 )
 
 // Filter represents a way of allowing or disallowing a card.
@@ -34,6 +38,13 @@ func FilterAND(filters ...Filter) Filter {
 	}
 }
 
+// FilterNOT inverts a filter, so that cards are only allowed if the filter given doesn't allow them.
+func FilterNOT(filter Filter) Filter {
+	return func(card *Card) bool {
+		return !filter(card)
+	}
+}
+
 // FilterPaths returns a filter that only allows cards who's path begins with the paths specified.
 // This is an OR operation -- if any of the paths given match, then the card is allowed.
 func FilterPaths(paths ...string) Filter {
@@ -92,7 +103,7 @@ func FilterBeforeDate(date time.Time) Filter {
 	}
 }
 
-// FilterAfterDate returns a filter that only allows cards that were created before a certain date.
+// FilterAfterDate returns a filter that only allows cards that were created after a certain date.
 func FilterAfterDate(date time.Time) Filter {
 	return func(card *Card) bool {
 		return card.Date.After(date)
@@ -115,6 +126,480 @@ func FilterBeforeDuration(duration time.Duration) Filter {
 // only lets cards created less than 30 days ago be used.
 func FilterAfterDuration(duration time.Duration) Filter {
 	return func(card *Card) bool {
-		return time.Since(card.Date) > duration
+		return time.Since(card.Date) < duration
+	}
+}
+
+// FilterLastAnsweredBeforeDate returns a filter that only allows cards that were last completed before a certain date.
+// Cards that have never been completed aren't allowed.
+func FilterLastAnsweredBeforeDate(date time.Time) Filter {
+	return func(card *Card) bool {
+		last, ok := card.LastCompletion()
+		return ok && last.Date.Before(date)
+	}
+}
+
+// FilterLastAnsweredAfterDate returns a filter that only allows cards that were last completed after a certain date.
+// Cards that have never been completed aren't allowed.
+func FilterLastAnsweredAfterDate(date time.Time) Filter {
+	return func(card *Card) bool {
+		last, ok := card.LastCompletion()
+		return ok && last.Date.After(date)
+	}
+}
+
+// FilterLastAnsweredBeforeDuration returns a filter that only allows cards last completed more than a certain amount
+// of time ago. For example
+//   FilterLastAnsweredBeforeDuration(7 * 24 * time.Hour)
+// only lets cards that haven't been answered in the last week be used. Cards that have never been completed aren't allowed.
+func FilterLastAnsweredBeforeDuration(duration time.Duration) Filter {
+	return func(card *Card) bool {
+		last, ok := card.LastCompletion()
+		return ok && time.Since(last.Date) > duration
+	}
+}
+
+// FilterLastAnsweredAfterDuration returns a filter that only allows cards last completed less than a certain amount
+// of time ago. For example
+//   FilterLastAnsweredAfterDuration(7 * 24 * time.Hour)
+// only lets cards that have been answered in the last week be used. Cards that have never been completed aren't allowed.
+func FilterLastAnsweredAfterDuration(duration time.Duration) Filter {
+	return func(card *Card) bool {
+		last, ok := card.LastCompletion()
+		return ok && time.Since(last.Date) < duration
+	}
+}
+
+// FilterMinMajors returns a filter that only allows cards that have been marked as a major mistake at least n times.
+func FilterMinMajors(n int) Filter {
+	return func(card *Card) bool {
+		return len(card.CompletionsMajor) >= n
+	}
+}
+
+// FilterLastResult returns a filter that only allows cards who's most recent completion was one of the types given
+// ("perfect", "minor" or "major"). This is an OR operation -- if the last completion matches any of the types given,
+// then the card is allowed. Cards that have never been completed aren't allowed.
+func FilterLastResult(completionTypes ...string) Filter {
+	return func(card *Card) bool {
+		last, ok := card.LastCompletion()
+		if !ok {
+			return false
+		}
+
+		for _, completionType := range completionTypes {
+			if last.Type == completionType {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// FilterMedianTimeOver returns a filter that only allows cards where the median time taken to complete them is more
+// than a certain duration. Cards that have never been completed aren't allowed.
+func FilterMedianTimeOver(duration time.Duration) Filter {
+	return func(card *Card) bool {
+		return card.TotalCompletions() > 0 && card.MedianDuration() > duration
+	}
+}
+
+// FilterNeverPerfect returns a filter that only allows cards that have never been completed perfectly.
+// This includes cards that have never been completed at all.
+func FilterNeverPerfect() Filter {
+	return func(card *Card) bool {
+		return len(card.CompletionsPerfect) == 0
+	}
+}
+
+// ParseFilter compiles a textual query into a Filter. A query is made up of conditions combined using AND, OR, NOT and
+// parentheses, where NOT binds tightest and OR loosest. For example:
+//   path:further-maths/* AND (tag:@?exam OR tag:@?hard) AND completions.major > 2 AND created < 30d
+// The conditions available are:
+//   path:<path>              Cards who's path begins with <path>. If <path> contains a glob pattern like '*', cards
+//                            are allowed if the pattern matches their path or the path of any of their parents.
+//   tag:<tag>                Cards that have the tag <tag>.
+//   completions.<type> <op> <n>
+//                            Cards that have been completed a certain number of times, where <type> is "perfect",
+//                            "minor", "major" or "total" and <op> is one of =, !=, <, <=, > or >=.
+//   created <op> <duration>  Cards created less than (<) or more than (>) a certain amount of time ago, like 30d or 2w.
+//   created <op> <date>      Cards created before (<) or after (>) a date in the format 2006-01-02.
+// Values containing spaces can be quoted, like tag:"@?past paper". Keywords are case-insensitive.
+func ParseFilter(query string) (Filter, error) {
+	tokens, err := lexQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := &queryParser{tokens: tokens}
+
+	filter, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if parser.pos < len(parser.tokens) {
+		return nil, fmt.Errorf("unexpected %q in query at position %d", parser.tokens[parser.pos].value, parser.tokens[parser.pos].offset)
+	}
+
+	return filter, nil
+}
+
+// queryTokenKind is the type of a token in a filter query.
+type queryTokenKind int
+
+const (
+	queryTokenWord queryTokenKind = iota
+	queryTokenOperator
+	queryTokenOpen
+	queryTokenClose
+)
+
+// queryToken is a single token in a filter query.
+type queryToken struct {
+	kind   queryTokenKind
+	value  string
+	offset int
+
+	// quoted is true if the token was written in quotes, in which case it can't be a keyword.
+	quoted bool
+}
+
+// lexQuery splits a filter query into tokens.
+func lexQuery(query string) ([]queryToken, error) {
+	tokens := []queryToken{}
+
+	for i := 0; i < len(query); {
+		char := query[i]
+
+		switch {
+		case unicode.IsSpace(rune(char)):
+			i++
+
+		case char == '(':
+			tokens = append(tokens, queryToken{kind: queryTokenOpen, value: "(", offset: i})
+			i++
+
+		case char == ')':
+			tokens = append(tokens, queryToken{kind: queryTokenClose, value: ")", offset: i})
+			i++
+
+		case strings.ContainsRune("<>=!", rune(char)):
+			start := i
+			for i < len(query) && strings.ContainsRune("<>=!", rune(query[i])) {
+				i++
+			}
+
+			tokens = append(tokens, queryToken{kind: queryTokenOperator, value: query[start:i], offset: start})
+
+		default:
+			start := i
+			var word strings.Builder
+			quoted := false
+
+			for i < len(query) && !unicode.IsSpace(rune(query[i])) && !strings.ContainsRune("()<>=!", rune(query[i])) {
+				if query[i] != '"' {
+					word.WriteByte(query[i])
+					i++
+					continue
+				}
+
+				// Quoted sections can appear anywhere in a word, like tag:"@?past paper".
+				end := strings.IndexByte(query[i+1:], '"')
+				if end == -1 {
+					return nil, fmt.Errorf("unterminated quote in query at position %d", i)
+				}
+
+				word.WriteString(query[i+1 : i+1+end])
+				i += end + 2
+				quoted = true
+			}
+
+			tokens = append(tokens, queryToken{kind: queryTokenWord, value: word.String(), offset: start, quoted: quoted})
+		}
+	}
+
+	return tokens, nil
+}
+
+// queryParser is a recursive descent parser for filter queries.
+type queryParser struct {
+	tokens []queryToken
+	pos    int
+}
+
+// peek returns the next token without consuming it, or nil if there are no tokens left.
+func (parser *queryParser) peek() *queryToken {
+	if parser.pos >= len(parser.tokens) {
+		return nil
+	}
+
+	return &parser.tokens[parser.pos]
+}
+
+// next consumes and returns the next token, or returns an error if there are no tokens left.
+func (parser *queryParser) next(expecting string) (queryToken, error) {
+	token := parser.peek()
+	if token == nil {
+		return queryToken{}, fmt.Errorf("unexpected end of query, expecting %s", expecting)
+	}
+
+	parser.pos++
+	return *token, nil
+}
+
+// isKeyword reports whether the next token is the keyword given.
+func (parser *queryParser) isKeyword(keyword string) bool {
+	token := parser.peek()
+	return token != nil && token.kind == queryTokenWord && !token.quoted && strings.EqualFold(token.value, keyword)
+}
+
+// parseOr parses conditions separated by OR.
+func (parser *queryParser) parseOr() (Filter, error) {
+	filter, err := parser.parseAnd()
+	if err != nil {
+		return nil, err
 	}
+
+	filters := []Filter{filter}
+	for parser.isKeyword("OR") {
+		parser.pos++
+
+		filter, err := parser.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		filters = append(filters, filter)
+	}
+
+	if len(filters) == 1 {
+		return filters[0], nil
+	}
+
+	return FilterOR(filters...), nil
+}
+
+// parseAnd parses conditions separated by AND.
+func (parser *queryParser) parseAnd() (Filter, error) {
+	filter, err := parser.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	filters := []Filter{filter}
+	for parser.isKeyword("AND") {
+		parser.pos++
+
+		filter, err := parser.parseNot()
+		if err != nil {
+			return nil, err
+		}
+
+		filters = append(filters, filter)
+	}
+
+	if len(filters) == 1 {
+		return filters[0], nil
+	}
+
+	return FilterAND(filters...), nil
+}
+
+// parseNot parses a condition optionally preceded by NOT.
+func (parser *queryParser) parseNot() (Filter, error) {
+	if !parser.isKeyword("NOT") {
+		return parser.parseTerm()
+	}
+
+	parser.pos++
+
+	filter, err := parser.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	return FilterNOT(filter), nil
+}
+
+// parseTerm parses a single condition or a parenthesised query.
+func (parser *queryParser) parseTerm() (Filter, error) {
+	token, err := parser.next("a condition")
+	if err != nil {
+		return nil, err
+	}
+
+	switch token.kind {
+	case queryTokenOpen:
+		filter, err := parser.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		closing, err := parser.next("')'")
+		if err != nil {
+			return nil, err
+		}
+
+		if closing.kind != queryTokenClose {
+			return nil, fmt.Errorf("expected ')' in query at position %d, got %q", closing.offset, closing.value)
+		}
+
+		return filter, nil
+
+	case queryTokenWord:
+		if colon := strings.IndexByte(token.value, ':'); colon != -1 {
+			return parseQueryMatch(strings.ToLower(token.value[:colon]), token.value[colon+1:], token.offset)
+		}
+
+		operator, err := parser.next("a comparison operator")
+		if err != nil {
+			return nil, err
+		}
+
+		if operator.kind != queryTokenOperator {
+			return nil, fmt.Errorf("expected a comparison operator after %q in query at position %d, got %q", token.value, operator.offset, operator.value)
+		}
+
+		value, err := parser.next("a value")
+		if err != nil {
+			return nil, err
+		}
+
+		if value.kind != queryTokenWord {
+			return nil, fmt.Errorf("expected a value after %q in query at position %d, got %q", operator.value, value.offset, value.value)
+		}
+
+		return parseQueryComparison(strings.ToLower(token.value), operator.value, value.value, token.offset)
+	}
+
+	return nil, fmt.Errorf("unexpected %q in query at position %d", token.value, token.offset)
+}
+
+// parseQueryMatch turns a condition in the form field:value into a Filter.
+func parseQueryMatch(field, value string, offset int) (Filter, error) {
+	if value == "" {
+		return nil, fmt.Errorf("missing value for %q in query at position %d", field, offset)
+	}
+
+	switch field {
+	case "path":
+		if strings.ContainsAny(value, "*?[") {
+			return filterPathGlob(value)
+		}
+
+		return FilterPaths(value), nil
+
+	case "tag":
+		return FilterTags(value), nil
+	}
+
+	return nil, fmt.Errorf("unknown field %q in query at position %d", field, offset)
+}
+
+// parseQueryComparison turns a condition in the form field <op> value into a Filter.
+func parseQueryComparison(field, operator, value string, offset int) (Filter, error) {
+	if strings.HasPrefix(field, "completions.") {
+		var count func(card *Card) int
+
+		switch strings.TrimPrefix(field, "completions.") {
+		case "perfect":
+			count = func(card *Card) int { return len(card.CompletionsPerfect) }
+		case "minor":
+			count = func(card *Card) int { return len(card.CompletionsMinor) }
+		case "major":
+			count = func(card *Card) int { return len(card.CompletionsMajor) }
+		case "total":
+			count = func(card *Card) int { return card.TotalCompletions() }
+		default:
+			return nil, fmt.Errorf("unknown field %q in query at position %d, expecting completions.perfect, completions.minor, completions.major or completions.total", field, offset)
+		}
+
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q for %q in query at position %d", value, field, offset)
+		}
+
+		compare, err := queryCompare(operator)
+		if err != nil {
+			return nil, fmt.Errorf("%s in query at position %d", err, offset)
+		}
+
+		return func(card *Card) bool {
+			return compare(count(card) - n)
+		}, nil
+	}
+
+	if field != "created" {
+		return nil, fmt.Errorf("unknown field %q in query at position %d", field, offset)
+	}
+
+	// Comparing a date is done the natural way round, so "created < 2021-02-16" means before the 16th of February.
+	if date, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		switch operator {
+		case "<":
+			return FilterBeforeDate(date), nil
+		case ">":
+			return FilterAfterDate(date), nil
+		}
+
+		return nil, fmt.Errorf("invalid operator %q for a date in query at position %d, expecting < or >", operator, offset)
+	}
+
+	duration, err := parseDuration(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date or duration %q for %q in query at position %d", value, field, offset)
+	}
+
+	// Comparing a duration is done by age, so "created < 30d" means created less than 30 days ago.
+	switch operator {
+	case "<":
+		return FilterAfterDuration(duration), nil
+	case ">":
+		return FilterBeforeDuration(duration), nil
+	}
+
+	return nil, fmt.Errorf("invalid operator %q for a duration in query at position %d, expecting < or >", operator, offset)
+}
+
+// queryCompare returns a function that reports whether the difference between two numbers satisfies the comparison
+// operator given.
+func queryCompare(operator string) (func(difference int) bool, error) {
+	switch operator {
+	case "=", "==":
+		return func(difference int) bool { return difference == 0 }, nil
+	case "!=":
+		return func(difference int) bool { return difference != 0 }, nil
+	case "<":
+		return func(difference int) bool { return difference < 0 }, nil
+	case "<=":
+		return func(difference int) bool { return difference <= 0 }, nil
+	case ">":
+		return func(difference int) bool { return difference > 0 }, nil
+	case ">=":
+		return func(difference int) bool { return difference >= 0 }, nil
+	}
+
+	return nil, fmt.Errorf("invalid comparison operator %q", operator)
+}
+
+// filterPathGlob returns a filter that only allows cards where the glob pattern given matches the card's path or
+// the path of one of its parents. This means that "further-maths/*" will allow every card in further-maths.
+func filterPathGlob(pattern string) (Filter, error) {
+	_, err := path.Match(pattern, "")
+	if err != nil {
+		return nil, fmt.Errorf("invalid path pattern %q: %w", pattern, err)
+	}
+
+	return func(card *Card) bool {
+		components := strings.Split(card.Path, "/")
+		for i := 1; i <= len(components); i++ {
+			if matched, _ := path.Match(pattern, strings.Join(components[:i], "/")); matched {
+				return true
+			}
+		}
+
+		return false
+	}, nil
 }
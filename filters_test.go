@@ -0,0 +1,279 @@
+package sergeant
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testFilterCards returns a handful of cards to test filters against.
+func testFilterCards() []*Card {
+	now := time.Now()
+	day := 24 * time.Hour
+
+	return []*Card{
+		{
+			ID:               "complex",
+			Path:             "further-maths/core-pure-1/chapter-1-complex-numbers/ex1a/question-complex",
+			Date:             now.Add(-10 * day),
+			Tags:             []string{"@?exam"},
+			CompletionsMajor: []Completion{{}, {}, {}},
+		},
+		{
+			ID:                 "series",
+			Path:               "further-maths/core-pure-1/chapter-3-series/ex3a/question-series",
+			Date:               now.Add(-60 * day),
+			Tags:               []string{"@?hard"},
+			CompletionsPerfect: []Completion{{}},
+		},
+		{
+			ID:   "kinematics",
+			Path: "physics/mechanics/kinematics/question-kinematics",
+			Date: now.Add(-2 * day),
+			Tags: []string{"@?exam", "@?past paper"},
+		},
+	}
+}
+
+// TestParseFilterValid tests that ParseFilter compiles valid queries into the correct filters.
+func TestParseFilterValid(t *testing.T) {
+	testCases := []struct {
+		query   string
+		allowed []string
+	}{
+		{"path:further-maths", []string{"complex", "series"}},
+		{"path:further-maths/*", []string{"complex", "series"}},
+		{"path:*/core-pure-1/chapter-3-*", []string{"series"}},
+		{"tag:@?exam", []string{"complex", "kinematics"}},
+		{`tag:"@?past paper"`, []string{"kinematics"}},
+		{"tag:@?exam OR tag:@?hard", []string{"complex", "series", "kinematics"}},
+		{"path:further-maths/* AND (tag:@?exam OR tag:@?hard)", []string{"complex", "series"}},
+		{"path:further-maths/* AND (tag:@?exam OR tag:@?hard) AND completions.major > 2 AND created < 30d", []string{"complex"}},
+		{"NOT path:physics", []string{"complex", "series"}},
+		{"not tag:@?exam and not tag:@?hard", []string{}},
+		{"completions.total = 0", []string{"kinematics"}},
+		{"completions.perfect>=1", []string{"series"}},
+		{"completions.major != 3", []string{"series", "kinematics"}},
+		{"created > 4w", []string{"series"}},
+		{"created < 1w OR created > 9w", []string{"kinematics"}},
+		{"tag:@?exam AND NOT (completions.major > 0 OR created > 1w)", []string{"kinematics"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			filter, err := ParseFilter(tc.query)
+			if err != nil {
+				t.Errorf("not expecting error parsing query: %s", err)
+				return
+			}
+
+			allowed := []string{}
+			for _, card := range testFilterCards() {
+				if filter(card) {
+					allowed = append(allowed, card.ID)
+				}
+			}
+
+			assert.Equal(t, tc.allowed, allowed, "expected different cards to be allowed by the query")
+		})
+	}
+}
+
+// TestParseFilterInvalid tests that ParseFilter returns errors for invalid queries.
+func TestParseFilterInvalid(t *testing.T) {
+	testCases := []struct {
+		query string
+		err   string
+	}{
+		{"", "unexpected end of query, expecting a condition"},
+		{"path:", `missing value for "path" in query at position 0`},
+		{"colour:red", `unknown field "colour" in query at position 0`},
+		{"tag:@?exam AND", "unexpected end of query, expecting a condition"},
+		{"(tag:@?exam OR tag:@?hard", "unexpected end of query, expecting ')'"},
+		{"tag:@?exam)", `unexpected ")" in query at position 10`},
+		{"completions.major 2", `expected a comparison operator after "completions.major" in query at position 18, got "2"`},
+		{"completions.wrong > 2", `unknown field "completions.wrong" in query at position 0, expecting completions.perfect, completions.minor, completions.major or completions.total`},
+		{"completions.major > lots", `invalid number "lots" for "completions.major" in query at position 0`},
+		{"completions.major => 2", `invalid comparison operator "=>" in query at position 0`},
+		{"created < yesterday", `invalid date or duration "yesterday" for "created" in query at position 0`},
+		{"created = 30d", `invalid operator "=" for a duration in query at position 0, expecting < or >`},
+		{`tag:"@?exam`, "unterminated quote in query at position 4"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			_, err := ParseFilter(tc.query)
+			if err == nil {
+				t.Errorf("expected an error when parsing an invalid query, got nil")
+				return
+			}
+
+			assert.Equal(t, tc.err, err.Error(), "expected different error when parsing invalid query")
+		})
+	}
+}
+
+// TestParseDuration tests that parseDuration understands days and weeks as well as the standard units.
+func TestParseDuration(t *testing.T) {
+	testCases := []struct {
+		input    string
+		duration time.Duration
+	}{
+		{"30d", 30 * 24 * time.Hour},
+		{"2w", 14 * 24 * time.Hour},
+		{"1.5d", 36 * time.Hour},
+		{"2w3d12h", 17*24*time.Hour + 12*time.Hour},
+		{"1h30m", 90 * time.Minute},
+	}
+
+	for _, tc := range testCases {
+		duration, err := parseDuration(tc.input)
+		assert.NoError(t, err, "not expecting error parsing duration %q", tc.input)
+		assert.Equal(t, tc.duration, duration, "expected different duration for %q", tc.input)
+	}
+
+	for _, input := range []string{"", "d", "30", "3x"} {
+		_, err := parseDuration(input)
+		assert.Error(t, err, "expected error parsing invalid duration %q", input)
+	}
+}
+
+// TestFormatDuration tests that formatDuration uses weeks and days where it can and can be parsed by parseDuration.
+func TestFormatDuration(t *testing.T) {
+	testCases := []struct {
+		duration  time.Duration
+		formatted string
+	}{
+		{7 * 24 * time.Hour, "1w"},
+		{90 * 24 * time.Hour, "12w6d"},
+		{36 * time.Hour, "1d12h"},
+		{90 * time.Minute, "1h30m"},
+		{90 * time.Second, "1m30s"},
+	}
+
+	for _, tc := range testCases {
+		formatted := formatDuration(tc.duration)
+		assert.Equal(t, tc.formatted, formatted, "expected different format for %s", tc.duration)
+
+		duration, err := parseDuration(formatted)
+		assert.NoError(t, err, "not expecting error parsing formatted duration %q", formatted)
+		assert.Equal(t, tc.duration, duration, "expected formatted duration %q to parse to the same duration", formatted)
+	}
+}
+
+// TestCompletionHistoryFilters tests the filters that look at a card's completions rather than when it was created.
+func TestCompletionHistoryFilters(t *testing.T) {
+	now := time.Now()
+	day := 24 * time.Hour
+
+	cards := []*Card{
+		{
+			ID:                 "improving",
+			CompletionsMajor:   []Completion{{Date: now.Add(-20 * day), Duration: 10 * time.Minute}, {Date: now.Add(-15 * day), Duration: 12 * time.Minute}},
+			CompletionsPerfect: []Completion{{Date: now.Add(-2 * day), Duration: 4 * time.Minute}},
+		},
+		{
+			ID:               "struggling",
+			CompletionsMinor: []Completion{{Date: now.Add(-30 * day), Duration: 8 * time.Minute}},
+			CompletionsMajor: []Completion{{Date: now.Add(-10 * day), Duration: 15 * time.Minute}, {Date: now.Add(-9 * day), Duration: 14 * time.Minute}},
+		},
+		{
+			ID: "unseen",
+		},
+	}
+
+	testCases := []struct {
+		name    string
+		filter  Filter
+		allowed []string
+	}{
+		{"LastAnsweredBeforeDuration", FilterLastAnsweredBeforeDuration(7 * day), []string{"struggling"}},
+		{"LastAnsweredAfterDuration", FilterLastAnsweredAfterDuration(7 * day), []string{"improving"}},
+		{"LastAnsweredBeforeDate", FilterLastAnsweredBeforeDate(now.Add(-5 * day)), []string{"struggling"}},
+		{"LastAnsweredAfterDate", FilterLastAnsweredAfterDate(now.Add(-5 * day)), []string{"improving"}},
+		{"MinMajors", FilterMinMajors(2), []string{"improving", "struggling"}},
+		{"LastResultMajor", FilterLastResult("major"), []string{"struggling"}},
+		{"LastResultMinorOrPerfect", FilterLastResult("minor", "perfect"), []string{"improving"}},
+		{"MedianTimeOver", FilterMedianTimeOver(11 * time.Minute), []string{"struggling"}},
+		{"NeverPerfect", FilterNeverPerfect(), []string{"struggling", "unseen"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			allowed := []string{}
+			for _, card := range cards {
+				if tc.filter(card) {
+					allowed = append(allowed, card.ID)
+				}
+			}
+
+			assert.Equal(t, tc.allowed, allowed, "expected different cards to be allowed by the filter")
+		})
+	}
+}
+
+// TestConfigSetExclude tests that sets can exclude cards by path and by tag.
+func TestConfigSetExclude(t *testing.T) {
+	cards := []*Card{
+		{ID: "chapter-1", Path: "further-maths/chapter-1/question-1"},
+		{ID: "chapter-2", Path: "further-maths/chapter-2/question-1"},
+		{ID: "skipped", Path: "further-maths/chapter-2/question-2", Tags: []string{"@?skip"}},
+		{ID: "hard", Path: "further-maths/chapter-3/question-1", Tags: []string{"@?hard"}},
+	}
+
+	set := ConfigSet{
+		PathsOr:      []string{"further-maths"},
+		ExcludePaths: []string{"further-maths/chapter-1"},
+		ExcludeTags:  []string{"@?skip", "@?hard"},
+	}
+
+	filter := set.AsFilter()
+
+	allowed := []string{}
+	for _, card := range cards {
+		if filter(card) {
+			allowed = append(allowed, card.ID)
+		}
+	}
+
+	assert.Equal(t, []string{"chapter-2"}, allowed, "expected excluded paths and cards with any excluded tag to be removed")
+}
+
+// TestConfigSetDurations tests that before-duration and after-duration can be used together to pick the cards created
+// within a window, like between 90 and 30 days ago.
+func TestConfigSetDurations(t *testing.T) {
+	now := time.Now()
+	day := 24 * time.Hour
+
+	cards := []*Card{
+		{ID: "new", Date: now.Add(-10 * day)},
+		{ID: "window", Date: now.Add(-60 * day)},
+		{ID: "old", Date: now.Add(-120 * day)},
+	}
+
+	testCases := []struct {
+		name    string
+		set     ConfigSet
+		allowed []string
+	}{
+		{"Before", ConfigSet{BeforeDuration: 30 * day}, []string{"window", "old"}},
+		{"After", ConfigSet{AfterDuration: 90 * day}, []string{"new", "window"}},
+		{"Window", ConfigSet{BeforeDuration: 30 * day, AfterDuration: 90 * day}, []string{"window"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			filter := tc.set.AsFilter()
+
+			allowed := []string{}
+			for _, card := range cards {
+				if filter(card) {
+					allowed = append(allowed, card.ID)
+				}
+			}
+
+			assert.Equal(t, tc.allowed, allowed, "expected different cards to be allowed by the set")
+		})
+	}
+}
@@ -1,6 +1,6 @@
 module github.com/albatross-org/sergeant
 
-go 1.15
+go 1.16
 
 require (
 	github.com/albatross-org/go-albatross v0.1.0
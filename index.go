@@ -0,0 +1,248 @@
+package sergeant
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/albatross-org/go-albatross/entries"
+	"github.com/segmentio/fasthash/fnv1"
+)
+
+// DefaultReloadInterval is how often the card index checks the underlying store for changes made outside of the
+// program, such as by running 'sergeant add' while the server is running. Checking only looks at when files were last
+// modified, and the entries are only read again if something has changed.
+var DefaultReloadInterval = time.Second
+
+// cardIndex is an in-memory cache of all the parsed cards in a store, indexed by both path and ID.
+// Parsing every entry into a card on every request is slow once there are a few thousand cards, so instead the index
+// keeps a fingerprint of every entry it has seen and only re-parses the ones that have changed when it's reloaded.
+type cardIndex struct {
+	mu sync.RWMutex
+
+	byPath map[string]*indexedCard
+	byID   map[string]*indexedCard
+
+	// warnings holds the parse errors for any malformed entries, mapped by path.
+	warnings map[string]*indexedWarning
+
+	// loadedAt is when the index was last synchronised with the underlying store. It is the zero time if the index
+	// has never been loaded.
+	loadedAt time.Time
+
+	// checkedAt is when the underlying store was last checked for changes, and token is the change token it had when
+	// the index was last synchronised. A token of zero means it couldn't be worked out.
+	checkedAt time.Time
+	token     uint64
+}
+
+// indexedCard is a card held in the index, along with the fingerprint of the entry it was parsed from.
+type indexedCard struct {
+	card        *Card
+	fingerprint uint64
+}
+
+// indexedWarning is a parse error held in the index, along with the fingerprint of the entry that caused it.
+type indexedWarning struct {
+	err         error
+	fingerprint uint64
+}
+
+// newCardIndex returns a new, empty cardIndex.
+func newCardIndex() *cardIndex {
+	return &cardIndex{
+		byPath:   map[string]*indexedCard{},
+		byID:     map[string]*indexedCard{},
+		warnings: map[string]*indexedWarning{},
+	}
+}
+
+// due reports whether the underlying store should be checked for changes, which is when the index has never been
+// loaded or it hasn't been checked for at least interval. The check is counted as done once it's reported as due.
+func (index *cardIndex) due(interval time.Duration) bool {
+	index.mu.Lock()
+	defer index.mu.Unlock()
+
+	if !index.loadedAt.IsZero() && time.Since(index.checkedAt) < interval {
+		return false
+	}
+
+	index.checkedAt = time.Now()
+	return true
+}
+
+// changed reports whether the index needs to be synchronised with an underlying store that has the given change token.
+func (index *cardIndex) changed(token uint64) bool {
+	index.mu.RLock()
+	defer index.mu.RUnlock()
+
+	return index.loadedAt.IsZero() || token == 0 || token != index.token
+}
+
+// sync updates the index to reflect the entries given, which should be every entry in the store, and remembers the
+// change token the store had before they were read. Entries that have the same fingerprint as last time aren't parsed
+// again.
+func (index *cardIndex) sync(slice []*entries.Entry, token uint64) {
+	index.mu.Lock()
+	defer index.mu.Unlock()
+
+	seen := map[string]bool{}
+
+	for _, entry := range slice {
+		seen[entry.Path] = true
+		fingerprint := entryFingerprint(entry)
+
+		if existing, ok := index.byPath[entry.Path]; ok && existing.fingerprint == fingerprint {
+			continue
+		}
+
+		if existing, ok := index.warnings[entry.Path]; ok && existing.fingerprint == fingerprint {
+			continue
+		}
+
+		index.put(entry, fingerprint)
+	}
+
+	for path := range index.byPath {
+		if !seen[path] {
+			index.remove(path)
+		}
+	}
+
+	for path := range index.warnings {
+		if !seen[path] {
+			index.remove(path)
+		}
+	}
+
+	index.loadedAt = time.Now()
+	index.checkedAt = index.loadedAt
+	index.token = token
+}
+
+// update re-parses a single entry, such as after the program has written to it.
+func (index *cardIndex) update(entry *entries.Entry) {
+	index.mu.Lock()
+	defer index.mu.Unlock()
+
+	index.put(entry, entryFingerprint(entry))
+}
+
+// delete removes the card at a path, such as after the entry has been moved or deleted.
+func (index *cardIndex) delete(path string) {
+	index.mu.Lock()
+	defer index.mu.Unlock()
+
+	index.remove(path)
+}
+
+// put parses an entry and stores the result, replacing anything previously stored at the same path.
+// The caller must hold the write lock.
+func (index *cardIndex) put(entry *entries.Entry, fingerprint uint64) {
+	index.remove(entry.Path)
+
+	card, err := cardFromEntry(entry)
+	if err != nil {
+		index.warnings[entry.Path] = &indexedWarning{err: err, fingerprint: fingerprint}
+		return
+	}
+
+	indexed := &indexedCard{card: card, fingerprint: fingerprint}
+	index.byPath[card.Path] = indexed
+	index.byID[card.ID] = indexed
+}
+
+// remove deletes anything stored at a path. The caller must hold the write lock.
+func (index *cardIndex) remove(path string) {
+	if existing, ok := index.byPath[path]; ok {
+		delete(index.byID, existing.card.ID)
+		delete(index.byPath, path)
+	}
+
+	delete(index.warnings, path)
+}
+
+// cards returns all the cards in the index, along with any warnings.
+// The cards returned are shared with the index and shouldn't be modified.
+func (index *cardIndex) cards() ([]*Card, map[string]error) {
+	index.mu.RLock()
+	defer index.mu.RUnlock()
+
+	cards := make([]*Card, 0, len(index.byPath))
+	for _, indexed := range index.byPath {
+		cards = append(cards, indexed.card)
+	}
+
+	warnings := map[string]error{}
+	for path, warning := range index.warnings {
+		warnings[path] = warning.err
+	}
+
+	return cards, warnings
+}
+
+// getByID returns the card with the given ID, or nil if there isn't one.
+func (index *cardIndex) getByID(id string) *Card {
+	index.mu.RLock()
+	defer index.mu.RUnlock()
+
+	if indexed, ok := index.byID[id]; ok {
+		return indexed.card
+	}
+
+	return nil
+}
+
+// getByPath returns the card at the given path, or nil if there isn't one.
+func (index *cardIndex) getByPath(path string) *Card {
+	index.mu.RLock()
+	defer index.mu.RUnlock()
+
+	if indexed, ok := index.byPath[path]; ok {
+		return indexed.card
+	}
+
+	return nil
+}
+
+// entryFingerprint returns a hash of everything in an entry that's used to create a card. If the fingerprint of an
+// entry hasn't changed, there's no need to parse it again.
+func entryFingerprint(entry *entries.Entry) uint64 {
+	// fmt prints maps with their keys sorted, so this is stable between calls.
+	return fnv1.HashString64(fmt.Sprintf(
+		"%s\x00%s\x00%v\x00%v\x00%v\x00%v\x00%s",
+		entry.Path, entry.Title, entry.Date.UnixNano(), entry.Tags, entry.Metadata, entry.Attachments, entry.Contents,
+	))
+}
+
+// changeToken returns a hash of the path, size and modification time of every file in a directory, so that changes to
+// any of them can be noticed without reading them. Git's directory is skipped since it changes without the entries
+// changing.
+func changeToken(dir string) (uint64, error) {
+	hash := fnv1.Init64
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Directories change whenever the files in them are added or removed, which already changes the token.
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		hash = fnv1.AddString64(hash, fmt.Sprintf("%s\x00%d\x00%d\x00", path, info.Size(), info.ModTime().UnixNano()))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return hash, nil
+}
@@ -0,0 +1,100 @@
+package sergeant
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/albatross-org/go-albatross/entries"
+	"github.com/stretchr/testify/assert"
+)
+
+// testIndexEntry returns a minimal valid card entry with the given ID.
+func testIndexEntry(id string) *entries.Entry {
+	return &entries.Entry{
+		Path:  "testing/question-" + id,
+		Title: "Question " + id,
+		Attachments: []entries.Attachment{
+			{AbsPath: "question.png", Name: "question.png"},
+			{AbsPath: "answer.png", Name: "answer.png"},
+		},
+		Metadata: map[string]interface{}{
+			"type":        "question",
+			"completions": map[interface{}]interface{}{},
+		},
+	}
+}
+
+// TestCardIndexSync tests that the card index only re-parses entries that have changed.
+func TestCardIndexSync(t *testing.T) {
+	index := newCardIndex()
+
+	first, second := testIndexEntry("first"), testIndexEntry("second")
+	index.sync([]*entries.Entry{first, second}, 0)
+
+	cards, warnings := index.cards()
+	assert.Len(t, cards, 2, "expected both cards to be indexed")
+	assert.Len(t, warnings, 0, "expected no warnings")
+
+	firstCard := index.getByID("first")
+	secondCard := index.getByPath("testing/question-second")
+
+	second.Contents = "Some new notes."
+	index.sync([]*entries.Entry{first, second}, 0)
+
+	assert.Same(t, firstCard, index.getByID("first"), "expected unchanged card not to be parsed again")
+	assert.NotSame(t, secondCard, index.getByID("second"), "expected changed card to be parsed again")
+	assert.Equal(t, "Some new notes.", index.getByID("second").Notes, "expected changed card to have new notes")
+
+	delete(first.Metadata, "type")
+	index.sync([]*entries.Entry{first}, 0)
+
+	cards, warnings = index.cards()
+	assert.Len(t, cards, 0, "expected malformed and removed cards to be dropped")
+	assert.Contains(t, warnings, first.Path, "expected warning for malformed card")
+	assert.Nil(t, index.getByID("first"), "expected malformed card not to be found by ID")
+}
+
+// TestChangeToken tests that the change token of a directory changes when files in it are added, changed or removed,
+// but not when nothing has happened.
+func TestChangeToken(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sergeant-entries-")
+	if err != nil {
+		t.Fatalf("couldn't create temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	entry := filepath.Join(dir, "question", "entry.md")
+	if !assert.NoError(t, os.MkdirAll(filepath.Dir(entry), 0755)) || !assert.NoError(t, ioutil.WriteFile(entry, []byte("first"), 0644)) {
+		return
+	}
+
+	first, err := changeToken(dir)
+	assert.NoError(t, err, "not expecting error getting change token")
+
+	second, err := changeToken(dir)
+	assert.NoError(t, err, "not expecting error getting change token")
+	assert.Equal(t, first, second, "expected token not to change when nothing has")
+
+	later := time.Now().Add(time.Minute)
+	assert.NoError(t, os.Chtimes(entry, later, later))
+
+	changed, _ := changeToken(dir)
+	assert.NotEqual(t, first, changed, "expected token to change when a file is modified")
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, ".git"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, ".git", "index"), []byte("git"), 0644))
+
+	withGit, _ := changeToken(dir)
+	assert.Equal(t, changed, withGit, "expected git's directory to be ignored")
+
+	assert.NoError(t, os.Remove(entry))
+
+	removed, _ := changeToken(dir)
+	assert.NotEqual(t, changed, removed, "expected token to change when a file is removed")
+
+	_, err = changeToken(filepath.Join(dir, "missing"))
+	assert.Error(t, err, "expected error for missing directory")
+}
@@ -0,0 +1,125 @@
+package sergeant
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// jsonRecord is something that can be kept in a jsonDirStore, like a Session or an Exam.
+type jsonRecord interface {
+	recordID() string
+	recordStarted() time.Time
+}
+
+// jsonDirStore keeps track of records and saves each one to its own JSON file in a directory whenever it changes.
+// It's used for both sessions and exams, which wrap it so that they get back their own types.
+type jsonDirStore struct {
+	mu   sync.Mutex
+	path string
+
+	// kind is what the records are called in errors, like "session".
+	kind string
+
+	// newRecord returns an empty record that a saved one can be unmarshalled into.
+	newRecord func() jsonRecord
+
+	// records is a cache of records that have been loaded from or saved to disk, mapped by ID.
+	records map[string]jsonRecord
+}
+
+// newJSONDirStore returns a new jsonDirStore that saves records in the directory given.
+func newJSONDirStore(path string, kind string, newRecord func() jsonRecord) *jsonDirStore {
+	return &jsonDirStore{
+		path:      path,
+		kind:      kind,
+		newRecord: newRecord,
+		records:   map[string]jsonRecord{},
+	}
+}
+
+// get returns the record with the given ID, loading it from disk if it hasn't been seen yet.
+// The caller must hold the lock.
+func (store *jsonDirStore) get(id string) (jsonRecord, error) {
+	if record, ok := store.records[id]; ok {
+		return record, nil
+	}
+
+	// IDs come from clients, so make sure they can't be used to read files outside of the directory.
+	if id == "" || strings.ContainsAny(id, `/\.`) {
+		return nil, fmt.Errorf("invalid %s ID %q", store.kind, id)
+	}
+
+	contentBytes, err := ioutil.ReadFile(filepath.Join(store.path, id+".json"))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s %q not found", store.kind, id)
+	} else if err != nil {
+		return nil, fmt.Errorf("couldn't read %s %q: %w", store.kind, id, err)
+	}
+
+	record := store.newRecord()
+	err = json.Unmarshal(contentBytes, record)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't unmarshal %s %q: %w", store.kind, id, err)
+	}
+
+	store.records[id] = record
+	return record, nil
+}
+
+// save writes a record to disk. The caller must hold the lock.
+func (store *jsonDirStore) save(record jsonRecord) error {
+	err := os.MkdirAll(store.path, 0755)
+	if err != nil {
+		return fmt.Errorf("couldn't create %ss directory %q: %w", store.kind, store.path, err)
+	}
+
+	contentBytes, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return fmt.Errorf("couldn't marshal %s %q: %w", store.kind, record.recordID(), err)
+	}
+
+	err = ioutil.WriteFile(filepath.Join(store.path, record.recordID()+".json"), contentBytes, 0644)
+	if err != nil {
+		return fmt.Errorf("couldn't write %s %q: %w", store.kind, record.recordID(), err)
+	}
+
+	store.records[record.recordID()] = record
+	return nil
+}
+
+// list returns every saved record, most recently started first. The caller must hold the lock.
+func (store *jsonDirStore) list() ([]jsonRecord, error) {
+	files, err := ioutil.ReadDir(store.path)
+	if os.IsNotExist(err) {
+		return []jsonRecord{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("couldn't read %ss directory %q: %w", store.kind, store.path, err)
+	}
+
+	list := []jsonRecord{}
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".json" {
+			continue
+		}
+
+		record, err := store.get(strings.TrimSuffix(file.Name(), ".json"))
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, record)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].recordStarted().After(list[j].recordStarted())
+	})
+
+	return list, nil
+}
@@ -0,0 +1,340 @@
+// Package pdf is a small PDF writer that can lay out text and images on pages.
+// It only supports what's needed to print worksheets: the standard Helvetica fonts, which every PDF reader has built
+// in so nothing needs to be embedded, and PNG, JPEG and GIF images.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	// These register the image formats that can be loaded.
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// The size of an A4 page in points, which is the unit used for all positions and sizes.
+const (
+	A4Width  = 595.28
+	A4Height = 841.89
+)
+
+// Font is one of the fonts that text can be written in.
+type Font int
+
+// The fonts available.
+const (
+	FontRegular Font = iota
+	FontBold
+)
+
+// fontNames are the names of the standard fonts used for each Font.
+var fontNames = map[Font]string{
+	FontRegular: "Helvetica",
+	FontBold:    "Helvetica-Bold",
+}
+
+// Document is a PDF document being built up in memory.
+// Positions are measured in points from the top left of the page.
+type Document struct {
+	width  float64
+	height float64
+
+	pages  []*page
+	images []*Image
+
+	// imageNumbers maps images to their position in images, starting from 1.
+	imageNumbers map[*Image]int
+}
+
+// page is a single page of a document.
+type page struct {
+	content bytes.Buffer
+	images  []*Image
+}
+
+// Image is an image that can be drawn on the pages of a document. The same image can be drawn more than once but is
+// only stored in the document once.
+type Image struct {
+	// Width and Height are the size of the image in pixels.
+	Width  int
+	Height int
+
+	data       []byte
+	filter     string
+	colorSpace string
+}
+
+// New returns a new, empty document with pages of the size given.
+func New(width, height float64) *Document {
+	return &Document{
+		width:        width,
+		height:       height,
+		imageNumbers: map[*Image]int{},
+	}
+}
+
+// Width returns the width of the document's pages.
+func (doc *Document) Width() float64 {
+	return doc.width
+}
+
+// Height returns the height of the document's pages.
+func (doc *Document) Height() float64 {
+	return doc.height
+}
+
+// AddPage starts a new page. Everything drawn afterwards goes on the new page.
+func (doc *Document) AddPage() {
+	doc.pages = append(doc.pages, &page{})
+}
+
+// Text writes a line of text with its baseline at y. Characters that can't be shown in the standard fonts are
+// replaced with question marks.
+func (doc *Document) Text(font Font, size, x, y float64, text string) {
+	page := doc.currentPage()
+	fmt.Fprintf(&page.content, "BT /F%d %.2f Tf %.2f %.2f Td (%s) Tj ET\n", font+1, size, x, doc.height-y, escapeText(text))
+}
+
+// Image draws an image with its top left corner at x, y, stretched to the width and height given.
+func (doc *Document) Image(img *Image, x, y, width, height float64) {
+	page := doc.currentPage()
+
+	number, ok := doc.imageNumbers[img]
+	if !ok {
+		doc.images = append(doc.images, img)
+		number = len(doc.images)
+		doc.imageNumbers[img] = number
+	}
+
+	page.images = append(page.images, img)
+	fmt.Fprintf(&page.content, "q %.2f 0 0 %.2f %.2f %.2f cm /Im%d Do Q\n", width, height, x, doc.height-y-height, number)
+}
+
+// currentPage returns the page currently being drawn on, adding one if there aren't any yet.
+func (doc *Document) currentPage() *page {
+	if len(doc.pages) == 0 {
+		doc.AddPage()
+	}
+
+	return doc.pages[len(doc.pages)-1]
+}
+
+// Write writes the document as a PDF file.
+func (doc *Document) Write(w io.Writer) error {
+	if len(doc.pages) == 0 {
+		doc.AddPage()
+	}
+
+	out := &pdfWriter{w: w}
+	out.printf("%%PDF-1.4\n%%\xe2\xe3\xcf\xd3\n")
+
+	// Objects are numbered: the catalog, the page tree, the two fonts, the images and then each page followed by its
+	// contents.
+	const catalog, pageTree, firstFont = 1, 2, 3
+	firstImage := firstFont + len(fontNames)
+	firstPage := firstImage + len(doc.images)
+
+	out.object(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pageTree))
+
+	kids := []string{}
+	for i := range doc.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", firstPage+2*i))
+	}
+
+	out.object(pageTree, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d /MediaBox [0 0 %.2f %.2f] >>", strings.Join(kids, " "), len(doc.pages), doc.width, doc.height))
+
+	for font := FontRegular; font <= FontBold; font++ {
+		out.object(firstFont+int(font), fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", fontNames[font]))
+	}
+
+	for i, img := range doc.images {
+		out.stream(firstImage+i, fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /%s /BitsPerComponent 8 /Filter /%s", img.Width, img.Height, img.colorSpace, img.filter), img.data)
+	}
+
+	for i, page := range doc.pages {
+		fonts := []string{}
+		for font := FontRegular; font <= FontBold; font++ {
+			fonts = append(fonts, fmt.Sprintf("/F%d %d 0 R", font+1, firstFont+int(font)))
+		}
+
+		xObjects := []string{}
+		seen := map[*Image]bool{}
+		for _, img := range page.images {
+			if !seen[img] {
+				number := doc.imageNumbers[img]
+				xObjects = append(xObjects, fmt.Sprintf("/Im%d %d 0 R", number, firstImage+number-1))
+				seen[img] = true
+			}
+		}
+
+		number := firstPage + 2*i
+		out.object(number, fmt.Sprintf(
+			"<< /Type /Page /Parent %d 0 R /Resources << /Font << %s >> /XObject << %s >> >> /Contents %d 0 R >>",
+			pageTree, strings.Join(fonts, " "), strings.Join(xObjects, " "), number+1,
+		))
+
+		content, err := compress(page.content.Bytes())
+		if err != nil {
+			return err
+		}
+
+		out.stream(number+1, "/Filter /FlateDecode", content)
+	}
+
+	xref := out.written
+	out.printf("xref\n0 %d\n0000000000 65535 f \n", len(out.offsets)+1)
+	for _, offset := range out.offsets {
+		out.printf("%010d 00000 n \n", offset)
+	}
+
+	out.printf("trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(out.offsets)+1, catalog, xref)
+
+	return out.err
+}
+
+// pdfWriter keeps track of where each object starts while a document is written, since PDF files end with a table
+// of the offsets of every object.
+type pdfWriter struct {
+	w       io.Writer
+	written int
+	offsets []int
+	err     error
+}
+
+// printf writes formatted output, remembering the first error that occurs.
+func (out *pdfWriter) printf(format string, args ...interface{}) {
+	out.write([]byte(fmt.Sprintf(format, args...)))
+}
+
+// write writes raw bytes, remembering the first error that occurs.
+func (out *pdfWriter) write(data []byte) {
+	if out.err != nil {
+		return
+	}
+
+	n, err := out.w.Write(data)
+	out.written += n
+	out.err = err
+}
+
+// object writes an object with the number given. Objects must be written in order.
+func (out *pdfWriter) object(number int, body string) {
+	out.offsets = append(out.offsets, out.written)
+	out.printf("%d 0 obj\n%s\nendobj\n", number, body)
+}
+
+// stream writes a stream object with the dictionary entries and data given.
+func (out *pdfWriter) stream(number int, dict string, data []byte) {
+	out.offsets = append(out.offsets, out.written)
+	out.printf("%d 0 obj\n<< %s /Length %d >>\nstream\n", number, dict, len(data))
+	out.write(data)
+	out.printf("\nendstream\nendobj\n")
+}
+
+// LoadImage reads an image from a file so that it can be drawn.
+func LoadImage(path string) (*Image, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read image %q: %w", path, err)
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("couldn't decode image %q: %w", path, err)
+	}
+
+	// PDFs can contain JPEGs as they are, so there's no need to decode them unless they use an unusual colour model.
+	if format == "jpeg" && (config.ColorModel == color.YCbCrModel || config.ColorModel == color.GrayModel) {
+		colorSpace := "DeviceRGB"
+		if config.ColorModel == color.GrayModel {
+			colorSpace = "DeviceGray"
+		}
+
+		return &Image{
+			Width:      config.Width,
+			Height:     config.Height,
+			data:       data,
+			filter:     "DCTDecode",
+			colorSpace: colorSpace,
+		}, nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("couldn't decode image %q: %w", path, err)
+	}
+
+	bounds := img.Bounds()
+	pixels := make([]byte, 0, bounds.Dx()*bounds.Dy()*3)
+
+	// PDF images don't have transparency, so transparent pixels are blended with white as if they'd been printed.
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := img.At(x, y).RGBA()
+			white := 0xffff - a
+
+			pixels = append(pixels, byte((r+white)>>8), byte((g+white)>>8), byte((b+white)>>8))
+		}
+	}
+
+	compressed, err := compress(pixels)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't compress image %q: %w", path, err)
+	}
+
+	return &Image{
+		Width:      bounds.Dx(),
+		Height:     bounds.Dy(),
+		data:       compressed,
+		filter:     "FlateDecode",
+		colorSpace: "DeviceRGB",
+	}, nil
+}
+
+// compress compresses data in the format expected by the FlateDecode filter.
+func compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	writer := zlib.NewWriter(&buf)
+
+	_, err := writer.Write(data)
+	if err != nil {
+		return nil, err
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// escapeText turns text into the contents of a PDF string in the WinAnsi encoding used by the standard fonts.
+func escapeText(text string) string {
+	var out strings.Builder
+
+	for _, r := range text {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			out.WriteRune('\\')
+			out.WriteRune(r)
+		case r >= 0x20 && r <= 0x7e:
+			out.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			// WinAnsi matches Latin-1 in this range, but the string is written as raw bytes so it needs an escape.
+			fmt.Fprintf(&out, "\\%03o", r)
+		default:
+			out.WriteRune('?')
+		}
+	}
+
+	return out.String()
+}
@@ -0,0 +1,86 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWrite tests that a document with text and images on several pages is written with a valid cross-reference
+// table, so that every object can be found where the table says it is.
+func TestWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sergeant-pdf-")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+	img.Set(0, 0, color.NRGBA{R: 255, A: 255})
+
+	imagePath := filepath.Join(dir, "question.png")
+	file, err := os.Create(imagePath)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, png.Encode(file, img))
+	file.Close()
+
+	loaded, err := LoadImage(imagePath)
+	if !assert.NoError(t, err, "expected no error loading image") {
+		return
+	}
+	assert.Equal(t, 4, loaded.Width)
+	assert.Equal(t, 2, loaded.Height)
+
+	doc := New(A4Width, A4Height)
+	doc.Text(FontBold, 20, 50, 50, "Worksheet (1)")
+	doc.Image(loaded, 50, 60, 100, 50)
+	doc.AddPage()
+	doc.Text(FontRegular, 10, 50, 50, "Answers \\ café ☃")
+	doc.Image(loaded, 50, 60, 100, 50)
+
+	var buf bytes.Buffer
+	if !assert.NoError(t, doc.Write(&buf), "expected no error writing document") {
+		return
+	}
+
+	out := buf.Bytes()
+	assert.True(t, bytes.HasPrefix(out, []byte("%PDF-1.4\n")), "expected PDF header")
+	assert.True(t, bytes.HasSuffix(out, []byte("%%EOF\n")), "expected PDF trailer")
+	assert.Equal(t, 1, bytes.Count(out, []byte("/Subtype /Image")), "expected image used twice to only be stored once")
+	assert.Equal(t, 2, bytes.Count(out, []byte("/Type /Page ")), "expected two pages")
+
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(out)
+	if !assert.NotNil(t, startxref, "expected startxref") {
+		return
+	}
+
+	xrefOffset, _ := strconv.Atoi(string(startxref[1]))
+	lines := strings.Split(string(out[xrefOffset:]), "\n")
+	assert.Equal(t, "xref", lines[0], "expected startxref to point at cross-reference table")
+
+	count, _ := strconv.Atoi(strings.Fields(lines[1])[1])
+	for number := 1; number < count; number++ {
+		offset, _ := strconv.Atoi(lines[2+number][:10])
+		header := fmt.Sprintf("%d 0 obj\n", number)
+
+		assert.Equal(t, header, string(out[offset:offset+len(header)]), "expected object %d at offset %d", number, offset)
+	}
+}
+
+// TestEscapeText tests that text is escaped so that it can be put in a PDF string.
+func TestEscapeText(t *testing.T) {
+	assert.Equal(t, `Worksheet \(1\) \\ caf\351 ?`, escapeText(`Worksheet (1) \ café ☃`))
+}
@@ -0,0 +1,233 @@
+package sergeant
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// DefaultProfile is the name of the profile whose completions are stored at the top level of each card. Stores with a
+// single learner only ever use the default profile.
+const DefaultProfile = ""
+
+// Profile is a single learner using a Store. Cards are shared between every profile, but each profile has its own
+// completions, so the sets, views and statistics for a profile only take into account how that learner has done.
+// The methods on a Profile mirror those on the Store, which act as the default profile.
+type Profile struct {
+	Name string
+
+	store *Store
+}
+
+// Profile returns the profile with the given name. Profiles don't need to be created first, a new profile just
+// doesn't have any completions yet. Names should be checked with ValidateProfileName if they come from users.
+func (store *Store) Profile(name string) *Profile {
+	return &Profile{
+		Name:  name,
+		store: store,
+	}
+}
+
+// ValidateProfileName checks that a profile name can be used as a key in a card's entry, only allowing letters, digits,
+// dashes and underscores. The empty string is the DefaultProfile and is also valid.
+func ValidateProfileName(name string) error {
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '-' && r != '_' {
+			return fmt.Errorf("invalid profile name %q: profile names can only contain letters, digits, dashes and underscores", name)
+		}
+	}
+
+	return nil
+}
+
+// Profiles returns the names of every profile that has completed a card, other than the default profile, in
+// alphabetical order.
+func (store *Store) Profiles() ([]string, error) {
+	cards, _, err := store.Cards()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	names := []string{}
+
+	for _, card := range cards {
+		for name := range card.Profiles {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
+
+// Cards returns every card in the store, as seen by the profile.
+// It returns the cards, followed by a map of warnings (paths -> parse errors) and an overall error if there was one.
+func (profile *Profile) Cards() ([]*Card, map[string]error, error) {
+	cards, warnings, err := profile.store.Cards()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if profile.Name == DefaultProfile {
+		return cards, warnings, nil
+	}
+
+	profileCards := make([]*Card, 0, len(cards))
+	for _, card := range cards {
+		profileCards = append(profileCards, card.ForProfile(profile.Name))
+	}
+
+	return profileCards, warnings, nil
+}
+
+// CardByID returns the card with the given ID, as seen by the profile.
+func (profile *Profile) CardByID(id string) (*Card, error) {
+	card, err := profile.store.CardByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return card.ForProfile(profile.Name), nil
+}
+
+// CardByPath returns the card at the given path, as seen by the profile.
+func (profile *Profile) CardByPath(path string) (*Card, error) {
+	card, err := profile.store.CardByPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return card.ForProfile(profile.Name), nil
+}
+
+// Set returns the cards present in the set specified, as seen by the profile.
+// It returns a Set, followed by a map of warnings (paths -> parse errors) and an overall error if there was one.
+func (profile *Profile) Set(name string) (*Set, map[string]error, error) {
+	config, ok := profile.store.ConfigSet(name)
+	if !ok || config.Name == "" {
+		return nil, nil, fmt.Errorf("set %q not found in config", name)
+	}
+
+	return profile.SetFromConfig(config)
+}
+
+// SetFromConfig returns the cards present in the set specified by the config, as seen by the profile. Filters that
+// look at completions, like last-result, use the profile's completions.
+// It returns a Set, followed by a map of warnings (paths -> parse errors) and an overall error if there was one.
+func (profile *Profile) SetFromConfig(config ConfigSet) (*Set, map[string]error, error) {
+	allCards, warnings, err := profile.Cards()
+	if err != nil {
+		return nil, nil, fmt.Errorf("couldn't create set from collection: %w", err)
+	}
+
+	filter := config.AsFilter()
+	cards := []*Card{}
+
+	for _, card := range allCards {
+		if filter(card) {
+			cards = append(cards, card)
+		}
+	}
+
+	return &Set{
+		Cards: cards,
+	}, warnings, nil
+}
+
+// AddCompletion adds a completion made by the profile to an entry in the store.
+func (profile *Profile) AddCompletion(path string, completionType string, completion Completion) error {
+	return profile.updateCompletions(path, func(card *Card) error {
+		return card.addCompletion(completionType, completion)
+	})
+}
+
+// RemoveCompletion removes the completion made by the profile at the given date (to the nearest minute) from the card
+// with the given ID. If date is the zero time, the profile's most recent completion is removed. The removed
+// completion is returned.
+func (profile *Profile) RemoveCompletion(id string, date time.Time) (TypedCompletion, error) {
+	card, err := profile.store.CardByID(id)
+	if err != nil {
+		return TypedCompletion{}, err
+	}
+
+	var removed TypedCompletion
+
+	err = profile.updateCompletions(card.Path, func(card *Card) error {
+		removed, err = card.removeCompletion(date)
+		return err
+	})
+	if err != nil {
+		return TypedCompletion{}, err
+	}
+
+	return removed, nil
+}
+
+// AmendCompletion changes the completion made by the profile at the given date (to the nearest minute) on the card
+// with the given ID to the completion type and completion specified. If the new completion's date or duration are
+// zero, the ones from the original completion are kept. This is useful for correcting a completion that was marked
+// with the wrong result. The amended completion is returned.
+func (profile *Profile) AmendCompletion(id string, date time.Time, completionType string, completion Completion) (TypedCompletion, error) {
+	card, err := profile.store.CardByID(id)
+	if err != nil {
+		return TypedCompletion{}, err
+	}
+
+	err = profile.updateCompletions(card.Path, func(card *Card) error {
+		original, err := card.removeCompletion(date)
+		if err != nil {
+			return err
+		}
+
+		if completion.Date.IsZero() {
+			completion.Date = original.Date
+		}
+
+		if completion.Duration == 0 {
+			completion.Duration = original.Duration
+		}
+
+		return card.addCompletion(completionType, completion)
+	})
+	if err != nil {
+		return TypedCompletion{}, err
+	}
+
+	return TypedCompletion{Type: completionType, Completion: completion}, nil
+}
+
+// CardStats returns the statistics for the card with the given ID using the profile's completions, predicting its
+// difficulty using every card.
+func (profile *Profile) CardStats(id string) (CardStats, error) {
+	card, err := profile.CardByID(id)
+	if err != nil {
+		return CardStats{}, err
+	}
+
+	cards, _, err := profile.Cards()
+	if err != nil {
+		return CardStats{}, err
+	}
+
+	return card.Stats(&Set{Cards: cards}), nil
+}
+
+// updateCompletions changes the profile's completions of the card at the given path. The change is given the card as
+// seen by the profile, and the other profiles' completions are left alone.
+func (profile *Profile) updateCompletions(path string, change func(card *Card) error) error {
+	return profile.store.updateCard(path, func(card *Card) error {
+		profileCard := card.ForProfile(profile.Name)
+
+		err := change(profileCard)
+		if err != nil {
+			return err
+		}
+
+		card.setProfileCompletions(profile.Name, profileCard)
+		return nil
+	})
+}
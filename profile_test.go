@@ -0,0 +1,49 @@
+package sergeant
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestProfileCompletions tests that completions made by a profile are kept separate from those of the default profile
+// and any other profiles.
+func TestProfileCompletions(t *testing.T) {
+	date := time.Date(2021, 02, 16, 10, 18, 0, 0, time.UTC)
+
+	card := &Card{
+		ID:                 "BtIrmFTJo49QuJC4",
+		CompletionsPerfect: []Completion{{Date: date, Duration: 7 * time.Minute}},
+		Profiles: map[string]ProfileCompletions{
+			"bob": {Major: []Completion{{Date: date, Duration: 3 * time.Minute}}},
+		},
+	}
+
+	assert.Same(t, card, card.ForProfile(DefaultProfile), "default profile should see the card itself")
+
+	alice := card.ForProfile("alice")
+	assert.Equal(t, 0, alice.TotalCompletions(), "new profile shouldn't have any completions")
+
+	err := alice.addCompletion("minor", Completion{Date: date.Add(time.Hour), Duration: 5 * time.Minute})
+	assert.Nil(t, err)
+
+	card.setProfileCompletions("alice", alice)
+
+	assert.Len(t, card.CompletionsPerfect, 1, "default profile's completions shouldn't change")
+	assert.Len(t, card.CompletionsMinor, 0, "default profile's completions shouldn't change")
+	assert.Len(t, card.ForProfile("alice").CompletionsMinor, 1)
+	assert.Len(t, card.ForProfile("bob").CompletionsMajor, 1, "other profiles' completions shouldn't change")
+	assert.Len(t, card.ForProfile("bob").CompletionsMinor, 0, "other profiles' completions shouldn't change")
+}
+
+// TestValidateProfileName tests that only profile names which can be used as keys in a card's entry are allowed.
+func TestValidateProfileName(t *testing.T) {
+	for _, name := range []string{DefaultProfile, "alice", "Bob-2", "year_13"} {
+		assert.Nil(t, ValidateProfileName(name), "expected %q to be valid", name)
+	}
+
+	for _, name := range []string{"alice smith", "../alice", "alice:", "élodie"} {
+		assert.NotNil(t, ValidateProfileName(name), "expected %q to be invalid", name)
+	}
+}
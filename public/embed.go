@@ -0,0 +1,24 @@
+//go:build webui
+// +build webui
+
+// Package public holds the web UI, so that it can be built into the binary and served without the repository being
+// around. The UI is only built in with the "webui" build tag, since it has to be built with "npm run build" first.
+package public
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed build
+var build embed.FS
+
+// UI returns the built web UI, with index.html at its root. It returns false if sergeant was built without it.
+func UI() (fs.FS, bool) {
+	ui, err := fs.Sub(build, "build")
+	if err != nil {
+		return nil, false
+	}
+
+	return ui, true
+}
@@ -0,0 +1,11 @@
+//go:build !webui
+// +build !webui
+
+package public
+
+import "io/fs"
+
+// UI returns the built web UI, with index.html at its root. It returns false if sergeant was built without it.
+func UI() (fs.FS, bool) {
+	return nil, false
+}
@@ -4,6 +4,7 @@ import { Switch, Route } from 'react-router-dom'
 import Home from './home/Home'
 import Choose from './sets/Choose'
 import Study from './sets/Study'
+import Login from './auth/Login'
 
 const Router = () => (
     <main>
@@ -11,6 +12,7 @@ const Router = () => (
             <Route exact path='/' component={Home} />
             <Route path='/sets/choose' component={Choose} />
             <Route path='/sets/study' component={Study} />
+            <Route path='/login' component={Login} />
         </Switch>
     </main>
 )
@@ -0,0 +1,23 @@
+// basePath is the path the web UI is being served under, like "/sergeant", taken from the <base> tag that sergeant adds
+// to index.html. It's blank when the UI is served at the root, or by "npm start" while working on it.
+export const basePath = (document.querySelector("base")?.getAttribute("href") || "/").replace(/\/$/, "")
+
+// apiEndpoint is where the API is. Normally this is the same server the UI came from, under the base path, so the API
+// is used over HTTPS when the UI is. While working on the UI with "npm start", REACT_APP_SERGEANT_API_ENDPOINT in
+// .env.development points at a separately running sergeant instead.
+const apiEndpoint = process.env.REACT_APP_SERGEANT_API_ENDPOINT || new URL("api", document.baseURI).toString()
+
+// apiURL returns the URL of an API method, like apiURL("sets/list").
+export function apiURL(path) {
+    return `${apiEndpoint}/v1/${path}`
+}
+
+// resolveURL turns a URL returned by the API, such as the image URLs of a card, into one that can be loaded.
+export function resolveURL(path) {
+    return new URL(path, apiEndpoint).toString()
+}
+
+// loginPath returns the path of the login page, which goes back to the page given once logged in.
+export function loginPath(redirect) {
+    return `/login?${new URLSearchParams({ redirect: redirect })}`
+}
@@ -0,0 +1,77 @@
+import React from "react"
+import { Section, Container, Heading, Box, Button, Form, Notification } from 'react-bulma-components';
+
+import { apiURL } from '../api'
+
+// Login asks for the password from the server's config, so that answers can be recorded when logging in is turned on.
+// Once logged in, it goes back to the page given in the redirect query parameter.
+class Login extends React.Component {
+    constructor(props) {
+        super(props)
+
+        this.state = {
+            password: "",
+            loading: false,
+            error: null,
+        }
+
+        this.handleChange = this.handleChange.bind(this)
+        this.handleSubmit = this.handleSubmit.bind(this)
+    }
+
+    handleChange(event) {
+        this.setState({ password: event.target.value })
+    }
+
+    handleSubmit(event) {
+        event.preventDefault()
+
+        let url = apiURL("auth/login")
+        console.log(`POST LOGIN ${url}`)
+        this.setState({ loading: true, error: null })
+
+        fetch(url, {
+            method: "POST",
+            credentials: "include",
+            body: JSON.stringify({ "password": this.state.password }),
+        })
+            .then(response => response.json())
+            .then(data => {
+                if (data.error) {
+                    this.setState({ loading: false, error: data.error })
+                } else {
+                    let redirect = new URLSearchParams(this.props.location.search).get("redirect")
+                    this.props.history.push(redirect || "/")
+                }
+            })
+            .catch(err => {
+                this.setState({ loading: false, error: "Error logging in: " + err })
+            })
+    }
+
+    render() {
+        return (
+            <Section>
+                <Container>
+                    <Box>
+                        <Heading>Log in</Heading>
+                        {this.state.error && <Notification color="danger">{this.state.error}</Notification>}
+                        <form onSubmit={this.handleSubmit}>
+                            <Form.Field>
+                                <Form.Label>Password</Form.Label>
+                                <Form.Control>
+                                    <Form.Input type="password" value={this.state.password} onChange={this.handleChange} autoFocus />
+                                </Form.Control>
+                            </Form.Field>
+                            <Button color="primary" type="submit" loading={this.state.loading}>
+                                Log in
+                            </Button>
+                        </form>
+                    </Box>
+                </Container>
+            </Section>
+        )
+    }
+}
+
+export default Login
@@ -1,6 +1,7 @@
 import React from 'react'
 import { Loader, Box } from 'react-bulma-components'
 import { ResponsiveCalendar } from '@nivo/calendar'
+import { apiURL } from '../api'
 
 // CalendarHeatmap is a calendar that shows a different colour depending on the value at a given day.
 class CalendarHeatmap extends React.Component {
@@ -28,9 +29,9 @@ class CalendarHeatmap extends React.Component {
 
     fetchStats() {
         // TODO: Graceful API request.
-        let url = `http://${process.env.REACT_APP_SERGEANT_API_ENDPOINT}/v1/sets/stats${this.query}`
+        let url = apiURL(`sets/stats${this.query}`)
         console.log(`GET STATS ${url}`)
-        fetch(url)
+        fetch(url, { credentials: "include" })
             .then(response => response.json())
             .then(data => {
                 this.setState({ data: data })
@@ -1,7 +1,8 @@
 import React from 'react'
-import { Link } from 'react-router-dom'
+import { Link, withRouter } from 'react-router-dom'
 import { Navbar } from 'react-bulma-components';
 import { v4 as uuid } from 'uuid'
+import { apiURL, loginPath } from '../api'
 
 class Header extends React.Component {
     constructor(props) {
@@ -10,14 +11,38 @@ class Header extends React.Component {
         this.state = {
             active: false,
             sets: props.sets,
+            auth: null,
         }
+
+        this.handleLogout = this.handleLogout.bind(this)
+    }
+
+    // fetchAuth finds out whether the server needs logging in to and if we have, for showing a login or logout link.
+    fetchAuth() {
+        let url = apiURL("auth/status")
+        console.log(`GET AUTH ${url}`)
+        fetch(url, { credentials: "include" })
+            .then(response => response.json())
+            .then(data => {
+                this.setState({auth: data})
+            })
+    }
+
+    handleLogout() {
+        let url = apiURL("auth/logout")
+        console.log(`POST LOGOUT ${url}`)
+        fetch(url, { method: "POST", credentials: "include" })
+            .then(response => response.json())
+            .then(data => {
+                this.setState({auth: data})
+            })
     }
 
     fetchSets() {
         // TODO: Graceful API request.
-        let url = `http://${process.env.REACT_APP_SERGEANT_API_ENDPOINT}/v1/sets/list`
+        let url = apiURL("sets/list")
         console.log(`GET SETS ${url}`)
-        fetch(url)
+        fetch(url, { credentials: "include" })
             .then(response => response.json())
             .then(data => {
                 this.setState({sets: data})
@@ -26,6 +51,14 @@ class Header extends React.Component {
 
     componentDidMount() {
         this.fetchSets()
+        this.fetchAuth()
+    }
+
+    componentDidUpdate(prevProps) {
+        // Logging in happens on its own page, so the status is checked again after going somewhere else.
+        if (prevProps.location.pathname !== this.props.location.pathname) {
+            this.fetchAuth()
+        }
     }
 
     render() {
@@ -70,6 +103,16 @@ class Header extends React.Component {
                         <Navbar.Item renderAs={Link} to="/settings">
                             Settings
                         </Navbar.Item>
+                        {this.state.auth?.enabled && !this.state.auth?.authenticated && (
+                            <Navbar.Item renderAs={Link} to={loginPath(this.props.location.pathname + this.props.location.search)}>
+                                Log in
+                            </Navbar.Item>
+                        )}
+                        {this.state.auth?.enabled && this.state.auth?.authenticated && (
+                            <Navbar.Item onClick={this.handleLogout}>
+                                Log out
+                            </Navbar.Item>
+                        )}
                     </Navbar.Container>
                 </Navbar.Menu>
             </Navbar>
@@ -78,4 +121,4 @@ class Header extends React.Component {
 }
 
 
-export default Header;
\ No newline at end of file
+export default withRouter(Header);
\ No newline at end of file
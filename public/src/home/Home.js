@@ -4,6 +4,7 @@ import { Section, Container, Heading, Box, Columns, Tile, Content } from 'react-
 import SetLink from '../common/SetLink'
 import CalendarHeatmap from '../common/CalendarHeatmap'
 import "./Home.css"
+import { apiURL } from '../api'
 
 class Home extends React.Component {
     constructor(props) {
@@ -14,9 +15,9 @@ class Home extends React.Component {
 
     fetchSets() {
         // TODO: Graceful API request.
-        let url = `http://${process.env.REACT_APP_SERGEANT_API_ENDPOINT}/v1/sets/list`
+        let url = apiURL("sets/list")
         console.log(`GET SETS ${url}`)
-        fetch(url)
+        fetch(url, { credentials: "include" })
             .then(response => response.json())
             .then(data => {
                 this.setState({ sets: data })
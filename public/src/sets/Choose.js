@@ -5,6 +5,7 @@ import { Link } from 'react-router-dom'
 import CalendarHeatmap from '../common/CalendarHeatmap'
 
 import "./Choose.css"
+import { apiURL } from '../api'
 
 class Choose extends React.Component {
     constructor(props) {
@@ -32,10 +33,10 @@ class Choose extends React.Component {
     }
 
     fetchSet() {
-        let url = `http://${process.env.REACT_APP_SERGEANT_API_ENDPOINT}/v1/sets/list`
+        let url = apiURL("sets/list")
         let name = new URLSearchParams(this.props.location.search).get("setName")
         console.log(`GET SETS ${url}`)
-        fetch(url)
+        fetch(url, { credentials: "include" })
             .then(response => response.json())
             .then(data => {
                 for (let set of data) {
@@ -98,6 +99,11 @@ class Choose extends React.Component {
                             description="Uses Bayesian inference to pick optimal cards."
                             name={this.state.set.name}
                         />
+                        <Option
+                            viewName="Spaced"
+                            description="Revisits cards you've answered before on a spaced repetition schedule."
+                            name={this.state.set.name}
+                        />
                     </Box>
                 </Container>
             </Section>
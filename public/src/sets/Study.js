@@ -4,6 +4,7 @@ import { Section, Container, Heading, Box, Columns, Hero, Button, Image, Breadcr
 import "./Study.css"
 
 import ReactTooltip from "react-tooltip";
+import { apiURL, resolveURL, loginPath } from "../api"
 
 const style = { textAlign: 'center', color: "white" };
 
@@ -125,11 +126,12 @@ class Study extends React.Component {
         }
 
         let duration = new Date().getTime() - this.state.cardTimeStart
-        let url = `http://${process.env.REACT_APP_SERGEANT_API_ENDPOINT}/v1/cards/update`
+        let url = apiURL("cards/update")
         console.log(`PUT CARD ${url}`)
 
         fetch(url, {
             method: "PUT",
+            credentials: "include",
             body: JSON.stringify({
                 "id": this.state.card.id,
                 "answer": answer,
@@ -139,6 +141,9 @@ class Study extends React.Component {
             .then(response => {
                 if (response.status == 200) {
                     this.fetchCard()
+                } else if (response.status == 401) {
+                    // Logging in is turned on, so come back to this set afterwards.
+                    this.props.history.push(loginPath(this.props.location.pathname + this.props.location.search))
                 } else {
                     console.log("Error PUT card: ", response)
                     this.setState({
@@ -167,9 +172,9 @@ class Study extends React.Component {
         // TODO: Graceful API request here.
         let params = new URLSearchParams(this.props.location.search)
 
-        let url = `http://${process.env.REACT_APP_SERGEANT_API_ENDPOINT}/v1/sets/get?${String(params)}`
+        let url = apiURL(`sets/get?${String(params)}`)
         console.log(`GET CARD ${url}`)
-        fetch(url)
+        fetch(url, { credentials: "include" })
             .then(response => response.json())
             .then(data => {
                 if (data.error) {
@@ -195,10 +200,10 @@ class Study extends React.Component {
 
     // fetchSet fetches the information about the current set.
     fetchSet() {
-        let url = `http://${process.env.REACT_APP_SERGEANT_API_ENDPOINT}/v1/sets/list`
+        let url = apiURL("sets/list")
         let name = new URLSearchParams(this.props.location.search).get("setName")
         console.log(`GET SETS ${url}`)
-        fetch(url)
+        fetch(url, { credentials: "include" })
             .then(response => response.json())
             .then(data => {
                 for (let set of data) {
@@ -235,8 +240,9 @@ class Study extends React.Component {
                         flipped={this.state.flipped}
 
                         path={this.state.card?.path}
-                        questionImg={this.state.card?.questionImg}
-                        answerImg={this.state.card?.answerImg}
+                        questionImgs={this.state.card?.questionImgs}
+                        answerImgs={this.state.card?.answerImgs}
+                        alternatives={this.state.card?.alternatives}
                     />
                 </Hero.Body>
                 <Hero.Footer className="study-box study-footer">
@@ -293,7 +299,38 @@ function Info(props) {
     )
 }
 
-// Card displays a flashcard.
+// imageURL turns the URL of a card's image into one that can be loaded from the API, asking for it to be scaled down
+// to the width of the screen so that phones don't have to download the full-size image.
+function imageURL(path) {
+    if (!path) {
+        return path
+    }
+
+    let url = new URL(resolveURL(path))
+    let width = Math.min(Math.ceil(window.innerWidth * (window.devicePixelRatio || 1)), 4096)
+    url.searchParams.set("width", width)
+
+    return url.toString()
+}
+
+// CardPage displays a single page of a card. Browsers can't show PDFs in an <img> tag, so they're embedded instead,
+// with a link in case the browser can't show them at all.
+function CardPage(props) {
+    if (props.img.type === "application/pdf") {
+        let url = resolveURL(props.img.url)
+
+        return (
+            <object className="card-pdf" data={url} type="application/pdf" hidden={props.hidden}>
+                <a href={url} target="_blank" rel="noreferrer">Open PDF</a>
+            </object>
+        )
+    }
+
+    return <img className="card-img" src={imageURL(props.img.url)} hidden={props.hidden} />
+}
+
+// Card displays a flashcard. Every page of the question is shown, and once it's flipped every page of the answer is
+// shown followed by any alternative solutions.
 function Card(props) {
     if (props.loading) {
         return (
@@ -312,16 +349,20 @@ function Card(props) {
             <Box className="card-box">
                 <Breadcrumb renderAs="a" hrefAttr="href" items={breadcrumbItems} />
                 <Container className="card-container">
-                    <img
-                        className="card-img"
-                        src={props.questionImg}
-                        hidden={props.flipped}
-                    />
-                    <img
-                        className="card-img"
-                        src={props.answerImg}
-                        hidden={!props.flipped}
-                    />
+                    {(props.questionImgs || []).map(img => (
+                        <CardPage key={img.url} img={img} hidden={props.flipped} />
+                    ))}
+                    {(props.answerImgs || []).map(img => (
+                        <CardPage key={img.url} img={img} hidden={!props.flipped} />
+                    ))}
+                    {(props.alternatives || []).map((pages, i) => (
+                        <div key={i} hidden={!props.flipped}>
+                            <Heading size={6}>Alternative {i + 1}</Heading>
+                            {pages.map(img => (
+                                <CardPage key={img.url} img={img} />
+                            ))}
+                        </div>
+                    ))}
                 </Container>
             </Box>
         );
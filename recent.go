@@ -0,0 +1,103 @@
+package sergeant
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultRecentCards and DefaultRecentDuration control how long a card is avoided for after it's been served, so that
+// skipping a card doesn't bring the same one straight back. A card is avoided if it was one of the last
+// DefaultRecentCards served, or if it was served less than DefaultRecentDuration ago.
+var (
+	DefaultRecentCards    = 10
+	DefaultRecentDuration = 10 * time.Minute
+)
+
+// servedCard is a record of a card being shown.
+type servedCard struct {
+	id string
+	at time.Time
+}
+
+// recentCards remembers which cards have recently been served outside of a session, such as through the
+// /api/v1/sets/get endpoint. Cards are remembered separately for each key, such as the name of the set they came from.
+type recentCards struct {
+	mu     sync.Mutex
+	served map[string][]servedCard
+
+	// pruned is when keys that haven't been used for a while were last removed.
+	pruned time.Time
+
+	count    int
+	duration time.Duration
+}
+
+// newRecentCards returns a new recentCards that remembers the last count cards, and any served in the last duration.
+func newRecentCards(count int, duration time.Duration) *recentCards {
+	return &recentCards{
+		served:   map[string][]servedCard{},
+		count:    count,
+		duration: duration,
+	}
+}
+
+// add records that a card was served.
+func (recent *recentCards) add(key string, id string, now time.Time) {
+	recent.mu.Lock()
+	defer recent.mu.Unlock()
+
+	served := append(recent.served[key], servedCard{id: id, at: now})
+
+	// Only what's needed for ids is kept, so the list doesn't grow forever.
+	for len(served) > recent.count && now.Sub(served[0].at) >= recent.duration {
+		served = served[1:]
+	}
+
+	recent.served[key] = served
+
+	// Keys can come from clients, like seeds, so ones that haven't been used for longer than duration are forgotten
+	// rather than being kept forever. This is only checked once every duration since it has to look at every key.
+	if now.Sub(recent.pruned) >= recent.duration {
+		for key, served := range recent.served {
+			if now.Sub(served[len(served)-1].at) >= recent.duration {
+				delete(recent.served, key)
+			}
+		}
+
+		recent.pruned = now
+	}
+}
+
+// ids returns the IDs of the cards that should currently be avoided for a key, most recent first.
+func (recent *recentCards) ids(key string, now time.Time) []string {
+	recent.mu.Lock()
+	defer recent.mu.Unlock()
+
+	return recentIDs(recent.served[key], now, recent.count, recent.duration)
+}
+
+// recentIDs returns the IDs of the cards in served, which should be oldest first, that were either one of the last
+// count served or were served less than duration before now. They're returned most recent first.
+func recentIDs(served []servedCard, now time.Time, count int, duration time.Duration) []string {
+	ids := []string{}
+
+	for i := len(served) - 1; i >= 0; i-- {
+		if len(ids) >= count && now.Sub(served[i].at) >= duration {
+			break
+		}
+
+		ids = append(ids, served[i].id)
+	}
+
+	return ids
+}
+
+// recentSet returns the recently served cards in a ViewContext as a set of IDs.
+func recentSet(ctx ViewContext) map[string]bool {
+	recent := map[string]bool{}
+	for _, id := range ctx.Recent {
+		recent[id] = true
+	}
+
+	return recent
+}
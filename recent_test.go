@@ -0,0 +1,69 @@
+package sergeant
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRecentCards tests that cards are remembered if they're one of the last few served or were served recently.
+func TestRecentCards(t *testing.T) {
+	start := time.Date(2021, 02, 16, 10, 18, 0, 0, time.UTC)
+	recent := newRecentCards(2, 10*time.Minute)
+
+	recent.add("set", "a", start)
+	recent.add("set", "b", start.Add(time.Minute))
+	recent.add("set", "c", start.Add(2*time.Minute))
+	recent.add("other", "d", start)
+
+	assert.Equal(t, []string{"c", "b", "a"}, recent.ids("set", start.Add(5*time.Minute)), "expected cards served in the last 10 minutes")
+	assert.Equal(t, []string{"c", "b"}, recent.ids("set", start.Add(time.Hour)), "expected the last two cards once the others are too old")
+	assert.Equal(t, []string{"d"}, recent.ids("other", start.Add(time.Hour)), "expected keys to be remembered separately")
+
+	recent.add("set", "e", start.Add(time.Hour))
+	assert.Len(t, recent.served["set"], 2, "expected old cards to be forgotten")
+	assert.NotContains(t, recent.served, "other", "expected keys that haven't been used for a while to be forgotten")
+}
+
+// TestViewsAvoidRecent tests that views don't serve recently served cards unless there's nothing else.
+func TestViewsAvoidRecent(t *testing.T) {
+	now := time.Now()
+
+	set := &Set{Cards: []*Card{
+		{ID: "a", Path: "maths/algebra/ex1/question-a"},
+		{ID: "b", Path: "maths/algebra/ex1/question-b"},
+	}}
+
+	// Both cards are due for the Spaced view, with a having been due for longer.
+	dueSet := &Set{Cards: []*Card{
+		{ID: "a", Path: "maths/algebra/ex1/question-a", CompletionsMajor: []Completion{{Date: now.Add(-72 * time.Hour)}}},
+		{ID: "b", Path: "maths/algebra/ex1/question-b", CompletionsMajor: []Completion{{Date: now.Add(-48 * time.Hour)}}},
+	}}
+
+	testCases := []struct {
+		name string
+		view ContextView
+		set  *Set
+	}{
+		{"Random", NewViewRandom(0), set},
+		{"Difficulties", NewViewDifficulties(0), set},
+		{"Bayesian", NewViewBayesian(0), set},
+		{"Unseen", NewViewUnseen(0), set},
+		{"Spaced", NewViewSpaced(), dueSet},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for i := 0; i < 20; i++ {
+				card := tc.view.NextWithContext(tc.set, ViewContext{Now: now, Recent: []string{"a"}})
+				if !assert.NotNil(t, card) || !assert.Equal(t, "b", card.ID, "expected recently served card to be avoided") {
+					return
+				}
+			}
+
+			card := tc.view.NextWithContext(tc.set, ViewContext{Now: now, Recent: []string{"a", "b"}})
+			assert.NotNil(t, card, "expected a card even if every card was served recently")
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// authCookie is the name of the cookie that holds someone's login token.
+const authCookie = "sergeant_login"
+
+// AuthLoginJSON is the JSON representation of a request to log in.
+type AuthLoginJSON struct {
+	Password string `json:"password"`
+}
+
+// AuthStatusJSON is the JSON representation of whether the server needs logging in to, and if the client has.
+type AuthStatusJSON struct {
+	Enabled       bool `json:"enabled"`
+	Authenticated bool `json:"authenticated"`
+}
+
+// logins keeps track of who's logged in, mapping login tokens to when they expire. They're only kept in memory, so
+// everyone has to log in again when the server restarts.
+type logins struct {
+	mu      sync.Mutex
+	expires map[string]time.Time
+}
+
+// newLogins returns an empty set of logins.
+func newLogins() *logins {
+	return &logins{
+		expires: map[string]time.Time{},
+	}
+}
+
+// create starts a new login lasting for the duration given and returns its token.
+func (logins *logins) create(duration time.Duration) (string, error) {
+	tokenBytes := make([]byte, 32)
+
+	_, err := rand.Read(tokenBytes)
+	if err != nil {
+		return "", err
+	}
+
+	token := hex.EncodeToString(tokenBytes)
+	now := time.Now()
+
+	logins.mu.Lock()
+	defer logins.mu.Unlock()
+
+	// Expired logins are cleared out here rather than in the background, since logging in doesn't happen often.
+	for existing, expires := range logins.expires {
+		if now.After(expires) {
+			delete(logins.expires, existing)
+		}
+	}
+
+	logins.expires[token] = now.Add(duration)
+
+	return token, nil
+}
+
+// valid reports whether the token belongs to a login that hasn't expired yet.
+func (logins *logins) valid(token string) bool {
+	logins.mu.Lock()
+	defer logins.mu.Unlock()
+
+	expires, ok := logins.expires[token]
+	return ok && time.Now().Before(expires)
+}
+
+// remove ends the login with the given token.
+func (logins *logins) remove(token string) {
+	logins.mu.Lock()
+	defer logins.mu.Unlock()
+
+	delete(logins.expires, token)
+}
+
+// Logging in with the wrong password is slowed down for each address so that the password can't be guessed quickly.
+// The first loginFreeAttempts in a row can be made straight away. After that, the wait before the next attempt starts
+// at a second and doubles every time, up to loginMaxDelay. Addresses are forgotten once they haven't tried to log in
+// for loginForgetAfter.
+const (
+	loginFreeAttempts = 5
+	loginMaxDelay     = 15 * time.Minute
+	loginForgetAfter  = time.Hour
+)
+
+// loginAttempt is how many times in a row an address has tried to log in without succeeding, and when it last tried.
+type loginAttempt struct {
+	count int
+	last  time.Time
+}
+
+// loginThrottle keeps track of the attempts to log in from each address.
+type loginThrottle struct {
+	mu       sync.Mutex
+	attempts map[string]*loginAttempt
+}
+
+// newLoginThrottle returns a loginThrottle that hasn't seen any attempts.
+func newLoginThrottle() *loginThrottle {
+	return &loginThrottle{
+		attempts: map[string]*loginAttempt{},
+	}
+}
+
+// begin records an attempt to log in from the address given. If the address has to wait before trying again, it
+// returns how long for and false instead. The attempt counts as a failure until succeed is called, so that lots of
+// attempts made at once can't get round the wait.
+func (throttle *loginThrottle) begin(addr string, now time.Time) (time.Duration, bool) {
+	throttle.mu.Lock()
+	defer throttle.mu.Unlock()
+
+	// Old attempts are cleared out here rather than in the background, like expired logins.
+	for existing, attempt := range throttle.attempts {
+		if now.Sub(attempt.last) >= loginForgetAfter {
+			delete(throttle.attempts, existing)
+		}
+	}
+
+	attempt := throttle.attempts[addr]
+	if attempt == nil {
+		attempt = &loginAttempt{}
+		throttle.attempts[addr] = attempt
+	}
+
+	if wait := loginDelay(attempt.count) - now.Sub(attempt.last); wait > 0 {
+		return wait, false
+	}
+
+	attempt.count++
+	attempt.last = now
+
+	return 0, true
+}
+
+// succeed forgets the attempts from an address once it has logged in.
+func (throttle *loginThrottle) succeed(addr string) {
+	throttle.mu.Lock()
+	defer throttle.mu.Unlock()
+
+	delete(throttle.attempts, addr)
+}
+
+// loginDelay returns how long an address has to wait after failing to log in count times in a row.
+func loginDelay(count int) time.Duration {
+	if count < loginFreeAttempts {
+		return 0
+	}
+
+	delay := time.Second
+	for i := loginFreeAttempts; i < count && delay < loginMaxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > loginMaxDelay {
+		return loginMaxDelay
+	}
+
+	return delay
+}
+
+// authenticated reports whether the request is allowed to use routes that need logging in to. This is true if
+// authentication is turned off, if it has one of the tokens from the config in an "Authorization: Bearer" header or
+// if it has the cookie of a login that hasn't expired.
+func authenticated(c *gin.Context) bool {
+	config := store.Config.Server
+
+	if !config.AuthEnabled() {
+		return true
+	}
+
+	if header := c.GetHeader("Authorization"); strings.HasPrefix(header, "Bearer ") {
+		given := []byte(strings.TrimPrefix(header, "Bearer "))
+
+		for _, token := range config.Tokens {
+			if subtle.ConstantTimeCompare(given, []byte(token)) == 1 {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	token, err := c.Cookie(authCookie)
+	if err != nil {
+		return false
+	}
+
+	return activeLogins.valid(token)
+}
+
+// cookiePath returns the path that the login cookie is set for, which is the base path the server is running under.
+func cookiePath() string {
+	if options.BasePath == "" {
+		return "/"
+	}
+
+	return options.BasePath
+}
+
+// originAllowed reports whether pages from the origin given can use the API while logged in, according to the
+// allowed-origins in the config. Only origins that are listed by name count, not "*".
+func originAllowed(origin string) bool {
+	for _, allowed := range store.Config.Server.AllowedOrigins {
+		if strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// anyOriginAllowed reports whether the allowed-origins in the config include "*", so that pages from any origin can
+// use the API without the login cookie.
+func anyOriginAllowed() bool {
+	for _, allowed := range store.Config.Server.AllowedOrigins {
+		if allowed == "*" {
+			return true
+		}
+	}
+
+	return false
+}
@@ -1,39 +1,199 @@
 package server
 
-import "github.com/albatross-org/sergeant"
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
 
-// CardJSON is the response returned when a client asks for a card.
+	"github.com/albatross-org/sergeant"
+)
+
+// CardJSON is the response returned when a client asks for a card. The images are the URLs they can be downloaded
+// from, relative to the server. QuestionImg and AnswerImg are the first pages of the question and answer, and
+// QuestionImgs and AnswerImgs are every page. Alternatives are the pages of each alternative solution.
 type CardJSON struct {
-	Path        string `json:"path"`
-	QuestionImg string `json:"questionImg"`
-	AnswerImg   string `json:"answerImg"`
-	ID          string `json:"id"`
+	Path         string            `json:"path"`
+	QuestionImg  string            `json:"questionImg"`
+	AnswerImg    string            `json:"answerImg"`
+	QuestionImgs []CardImageJSON   `json:"questionImgs"`
+	AnswerImgs   []CardImageJSON   `json:"answerImgs"`
+	Alternatives [][]CardImageJSON `json:"alternatives"`
+	ID           string            `json:"id"`
+}
+
+// CardImageJSON is the JSON representation of a page of a card's question, answer or alternative solution. Type is
+// its media type, like "image/png" or "application/pdf", so that clients know how to display it.
+type CardImageJSON struct {
+	URL  string `json:"url"`
+	Type string `json:"type"`
 }
 
 // cardToJSON converts a *sergeant.Card into the JSON format ready to be accepted by the client.
-// If an error is returned, it's due to an issue with converting the card's contents to a data URI.
+// If an error is returned, it's due to an issue with finding the card's images.
 func cardToJSON(card *sergeant.Card) (CardJSON, error) {
-	questionImg, err := card.QuestionImage()
+	questionImgs, err := cardImagesToJSON(card, "question", card.QuestionPaths, 0)
 	if err != nil {
 		return CardJSON{}, err
 	}
 
-	answerImg, err := card.AnswerImage()
+	answerImgs, err := cardImagesToJSON(card, "answer", card.AnswerPaths, 0)
 	if err != nil {
 		return CardJSON{}, err
 	}
 
+	alternatives := [][]CardImageJSON{}
+	for i, pages := range card.Alternatives {
+		alternativeImgs, err := cardImagesToJSON(card, "answer", pages, i+1)
+		if err != nil {
+			return CardJSON{}, err
+		}
+
+		alternatives = append(alternatives, alternativeImgs)
+	}
+
 	return CardJSON{
-		Path:        card.PathParent(),
-		QuestionImg: questionImg,
-		AnswerImg:   answerImg,
-		ID:          card.ID,
+		Path:         card.PathParent(),
+		QuestionImg:  questionImgs[0].URL,
+		AnswerImg:    answerImgs[0].URL,
+		QuestionImgs: questionImgs,
+		AnswerImgs:   answerImgs,
+		Alternatives: alternatives,
+		ID:           card.ID,
 	}, nil
 }
 
+// cardImagesToJSON returns the URLs that the pages of a card's question or answer can be downloaded from, along with
+// their media types. If alternative isn't zero, the pages are of that alternative solution. The URLs include when each
+// image was last changed, so they can be cached until the image is replaced.
+func cardImagesToJSON(card *sergeant.Card, name string, imagePaths []string, alternative int) ([]CardImageJSON, error) {
+	if len(imagePaths) == 0 {
+		return nil, fmt.Errorf("card %q has no %s images", card.ID, name)
+	}
+
+	images := []CardImageJSON{}
+
+	for i, imagePath := range imagePaths {
+		info, err := os.Stat(imagePath)
+		if err != nil {
+			return nil, err
+		}
+
+		query := url.Values{}
+		query.Set("v", strconv.FormatInt(info.ModTime().UnixNano(), 36))
+
+		if i > 0 {
+			query.Set("page", strconv.Itoa(i+1))
+		}
+
+		if alternative != 0 {
+			query.Set("alternative", strconv.Itoa(alternative))
+		}
+
+		images = append(images, CardImageJSON{
+			URL:  fmt.Sprintf("%s/api/v1/cards/images/%s/%s?%s", options.BasePath, url.PathEscape(card.ID), name, query.Encode()),
+			Type: sergeant.MediaType(imagePath),
+		})
+	}
+
+	return images, nil
+}
+
 // CardUpdateJSON is what is sent to the server when a client wants to update a card.
 type CardUpdateJSON struct {
 	ID       string `json:"id"`
 	Answer   string `json:"answer"`
 	Duration int    `json:"duration"`
 }
+
+// CardUndoJSON is what is sent to the server when a client wants to remove a completion from a card.
+// If Date is blank, the card's most recent completion is removed.
+type CardUndoJSON struct {
+	ID   string `json:"id"`
+	Date string `json:"date"`
+}
+
+// CardAmendJSON is what is sent to the server when a client wants to change an existing completion on a card.
+// The completion is found using Date, and then replaced with a completion with the new Answer. If Duration is zero,
+// the original duration is kept.
+type CardAmendJSON struct {
+	ID       string `json:"id"`
+	Date     string `json:"date"`
+	Answer   string `json:"answer"`
+	Duration int    `json:"duration"`
+}
+
+// CompletionJSON is the JSON representation of a single completion of a card.
+type CompletionJSON struct {
+	Date     string `json:"date"`
+	Answer   string `json:"answer"`
+	Duration int    `json:"duration"`
+}
+
+// completionToJSON converts a sergeant.TypedCompletion into the JSON format ready to be accepted by the client.
+func completionToJSON(completion sergeant.TypedCompletion) CompletionJSON {
+	return CompletionJSON{
+		Date:     completion.Date.Format("2006-01-02 15:04"),
+		Answer:   completion.Type,
+		Duration: int(completion.Duration / time.Millisecond),
+	}
+}
+
+// CardStatsJSON is the JSON representation of the statistics for a single card.
+// Durations are in milliseconds, and LastSeen is blank if the card has never been completed.
+type CardStatsJSON struct {
+	ID   string `json:"id"`
+	Path string `json:"path"`
+
+	Attempts    int     `json:"attempts"`
+	Perfect     int     `json:"perfect"`
+	Minor       int     `json:"minor"`
+	Major       int     `json:"major"`
+	SuccessRate float64 `json:"successRate"`
+
+	MeanDuration   int `json:"meanDuration"`
+	MedianDuration int `json:"medianDuration"`
+
+	Streak   int    `json:"streak"`
+	LastSeen string `json:"lastSeen"`
+
+	Difficulty     float64 `json:"difficulty"`
+	DifficultyPath string  `json:"difficultyPath"`
+}
+
+// cardStatsToJSON converts a sergeant.CardStats into the JSON format ready to be accepted by the client.
+func cardStatsToJSON(card *sergeant.Card, stats sergeant.CardStats) CardStatsJSON {
+	lastSeen := ""
+	if !stats.LastSeen.IsZero() {
+		lastSeen = stats.LastSeen.Format("2006-01-02 15:04")
+	}
+
+	return CardStatsJSON{
+		ID:   card.ID,
+		Path: card.Path,
+
+		Attempts:    stats.Attempts,
+		Perfect:     stats.Perfect,
+		Minor:       stats.Minor,
+		Major:       stats.Major,
+		SuccessRate: stats.SuccessRate,
+
+		MeanDuration:   int(stats.MeanDuration / time.Millisecond),
+		MedianDuration: int(stats.MedianDuration / time.Millisecond),
+
+		Streak:   stats.Streak,
+		LastSeen: lastSeen,
+
+		Difficulty:     stats.Difficulty,
+		DifficultyPath: stats.DifficultyPath,
+	}
+}
+
+// CardEditJSON is what is sent to the server when a client wants to change a card. Fields that are left out aren't
+// changed. Path is the new category for the card, like "further-maths/core-pure-1/chapter-2-series", and Tags
+// replaces all of the card's tags.
+type CardEditJSON struct {
+	Path *string   `json:"path"`
+	Tags *[]string `json:"tags"`
+}
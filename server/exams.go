@@ -0,0 +1,169 @@
+package server
+
+import (
+	"time"
+
+	"github.com/albatross-org/sergeant"
+)
+
+// ExamJSON is the response returned when a client asks about an exam.
+type ExamJSON struct {
+	ID       string `json:"id"`
+	SetName  string `json:"setName"`
+	ViewName string `json:"viewName"`
+	Profile  string `json:"profile,omitempty"`
+
+	Started   string `json:"started"`
+	Submitted string `json:"submitted,omitempty"`
+
+	// TimeLimit is the total time allowed in milliseconds, or zero if there's no time limit. Remaining is how much of it
+	// is left, which is negative once the time limit has run out.
+	TimeLimit int  `json:"timeLimit"`
+	Remaining int  `json:"remaining"`
+	Expired   bool `json:"expired"`
+
+	// Current is the index of the question being shown, starting from zero, out of Questions.
+	Current   int `json:"current"`
+	Questions int `json:"questions"`
+
+	// Card is the card for the question currently being shown, if there is one.
+	Card *CardJSON `json:"card,omitempty"`
+
+	// CardElapsed is how long the current question has been shown for in milliseconds.
+	CardElapsed int `json:"cardElapsed"`
+
+	// Report is only given once the exam has been submitted.
+	Report *ExamReportJSON `json:"report,omitempty"`
+}
+
+// ExamReportJSON is the JSON representation of the summary of an exam. Durations are in milliseconds.
+type ExamReportJSON struct {
+	Questions  int `json:"questions"`
+	Perfect    int `json:"perfect"`
+	Minor      int `json:"minor"`
+	Major      int `json:"major"`
+	Skipped    int `json:"skipped"`
+	Unanswered int `json:"unanswered"`
+
+	Score     float64 `json:"score"`
+	Elapsed   int     `json:"elapsed"`
+	TimeLimit int     `json:"timeLimit"`
+	OverTime  bool    `json:"overTime"`
+
+	Topics []ExamTopicJSON `json:"topics"`
+
+	// Results holds the answer given to every question on the paper, in order. Unanswered questions have a blank answer.
+	Results []SessionResultJSON `json:"results"`
+}
+
+// ExamTopicJSON is the JSON representation of the results of an exam for a single category.
+type ExamTopicJSON struct {
+	Path       string `json:"path"`
+	Questions  int    `json:"questions"`
+	Perfect    int    `json:"perfect"`
+	Minor      int    `json:"minor"`
+	Major      int    `json:"major"`
+	Skipped    int    `json:"skipped"`
+	Unanswered int    `json:"unanswered"`
+}
+
+// examToJSON converts a *sergeant.Exam and the card for the question it's currently showing into the JSON format
+// ready to be accepted by the client. The card can be nil.
+// If an error is returned, it's due to an issue with converting the card's contents to a data URI.
+func examToJSON(exam *sergeant.Exam, card *sergeant.Card) (ExamJSON, error) {
+	now := time.Now()
+
+	examJSON := ExamJSON{
+		ID:        exam.ID,
+		SetName:   exam.SetName,
+		ViewName:  exam.ViewName,
+		Profile:   exam.Profile,
+		Started:   exam.Started.Format("2006-01-02 15:04"),
+		TimeLimit: int(exam.TimeLimit / time.Millisecond),
+		Remaining: int(exam.Remaining(now) / time.Millisecond),
+		Expired:   exam.Expired(now),
+		Current:   exam.Current,
+		Questions: len(exam.Questions),
+	}
+
+	if card != nil {
+		cardJSON, err := cardToJSON(card)
+		if err != nil {
+			return ExamJSON{}, err
+		}
+
+		examJSON.Card = &cardJSON
+		examJSON.CardElapsed = int(now.Sub(exam.CurrentServed) / time.Millisecond)
+	}
+
+	if exam.Finished() {
+		examJSON.Submitted = exam.Submitted.Format("2006-01-02 15:04")
+
+		report := examReportToJSON(exam)
+		examJSON.Report = &report
+	}
+
+	return examJSON, nil
+}
+
+// examReportToJSON returns the ExamReportJSON representation of a *sergeant.Exam's report.
+func examReportToJSON(exam *sergeant.Exam) ExamReportJSON {
+	report := exam.Report()
+
+	reportJSON := ExamReportJSON{
+		Questions:  report.Questions,
+		Perfect:    report.Perfect,
+		Minor:      report.Minor,
+		Major:      report.Major,
+		Skipped:    report.Skipped,
+		Unanswered: report.Unanswered,
+		Score:      report.Score,
+		Elapsed:    int(report.Elapsed / time.Millisecond),
+		TimeLimit:  int(report.TimeLimit / time.Millisecond),
+		OverTime:   report.OverTime,
+		Topics:     []ExamTopicJSON{},
+		Results:    []SessionResultJSON{},
+	}
+
+	for _, topic := range report.Topics {
+		reportJSON.Topics = append(reportJSON.Topics, ExamTopicJSON{
+			Path:       topic.Path,
+			Questions:  topic.Questions,
+			Perfect:    topic.Perfect,
+			Minor:      topic.Minor,
+			Major:      topic.Major,
+			Skipped:    topic.Skipped,
+			Unanswered: topic.Unanswered,
+		})
+	}
+
+	for _, question := range exam.Questions {
+		date := ""
+		if !question.Date.IsZero() {
+			date = question.Date.Format("2006-01-02 15:04")
+		}
+
+		reportJSON.Results = append(reportJSON.Results, SessionResultJSON{
+			ID:       question.CardID,
+			Path:     question.Path,
+			Answer:   question.Answer,
+			Date:     date,
+			Duration: int(question.Duration / time.Millisecond),
+		})
+	}
+
+	return reportJSON
+}
+
+// ExamAnswerJSON is what is sent to the server when a client answers or skips the current question in an exam.
+// If Duration is zero, the time since the question was first shown is used.
+type ExamAnswerJSON struct {
+	ID       string `json:"id"`
+	Answer   string `json:"answer"`
+	Duration int    `json:"duration"`
+}
+
+// ExamSubmitJSON is what is sent to the server when a client wants to submit an exam.
+type ExamSubmitJSON struct {
+	ID string `json:"id"`
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func handlerAuthLogin(c *gin.Context) {
+	login := &AuthLoginJSON{}
+
+	err := c.BindJSON(login)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("couldn't decode post request: %s", err),
+		})
+		return
+	}
+
+	config := store.Config.Server
+
+	if config.Password == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "logging in with a password isn't turned on in the config",
+		})
+		return
+	}
+
+	// The address the request came from is used rather than c.ClientIP, since headers like X-Forwarded-For can be
+	// made up to get round the throttle. Behind a reverse proxy, everyone shares the proxy's address.
+	addr, _, err := net.SplitHostPort(c.Request.RemoteAddr)
+	if err != nil {
+		addr = c.Request.RemoteAddr
+	}
+
+	if wait, ok := loginAttempts.begin(addr, time.Now()); !ok {
+		seconds := int(math.Ceil(wait.Seconds()))
+
+		c.Header("Retry-After", strconv.Itoa(seconds))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error": fmt.Sprintf("too many incorrect passwords, please try again in %d seconds", seconds),
+		})
+		return
+	}
+
+	if subtle.ConstantTimeCompare([]byte(login.Password), []byte(config.Password)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "incorrect password",
+		})
+		return
+	}
+
+	loginAttempts.succeed(addr)
+
+	token, err := activeLogins.create(config.SessionDuration)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("couldn't log in: %s", err),
+		})
+		return
+	}
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(authCookie, token, int(config.SessionDuration.Seconds()), cookiePath(), "", c.Request.TLS != nil, true)
+
+	c.JSON(http.StatusOK, AuthStatusJSON{Enabled: true, Authenticated: true})
+}
+
+func handlerAuthLogout(c *gin.Context) {
+	if token, err := c.Cookie(authCookie); err == nil {
+		activeLogins.remove(token)
+	}
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(authCookie, "", -1, cookiePath(), "", c.Request.TLS != nil, true)
+
+	c.JSON(http.StatusOK, AuthStatusJSON{
+		Enabled:       store.Config.Server.AuthEnabled(),
+		Authenticated: !store.Config.Server.AuthEnabled(),
+	})
+}
+
+func handlerAuthStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, AuthStatusJSON{
+		Enabled:       store.Config.Server.AuthEnabled(),
+		Authenticated: authenticated(c),
+	})
+}
@@ -1,8 +1,13 @@
 package server
 
 import (
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
 	"time"
 
 	"github.com/albatross-org/sergeant"
@@ -34,22 +39,23 @@ func handlerCardUpdate(c *gin.Context) {
 		return
 	}
 
-	set, _, err := store.Set("all")
+	profile, err := profileFromRequest(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("couldn't get set: %s", err),
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
 		})
 		return
 	}
 
-	var card *sergeant.Card
-	for _, searchCard := range set.Cards {
-		if searchCard.ID == answer.ID {
-			card = searchCard
-		}
+	card, err := profile.CardByID(answer.ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("couldn't get card: %s", err),
+		})
+		return
 	}
 
-	err = store.AddCompletion(card.Path, answer.Answer, sergeant.Completion{
+	err = profile.AddCompletion(card.Path, answer.Answer, sergeant.Completion{
 		Date:     time.Now(),
 		Duration: time.Millisecond * time.Duration(answer.Duration),
 	})
@@ -60,3 +66,493 @@ func handlerCardUpdate(c *gin.Context) {
 		return
 	}
 }
+
+func handlerCardUndo(c *gin.Context) {
+	undo := &CardUndoJSON{}
+
+	err := c.BindJSON(undo)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("couldn't decode post request: %s", err),
+		})
+		return
+	}
+
+	if undo.ID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "couldn't decode post request: the id field is blank",
+		})
+		return
+	}
+
+	var date time.Time
+	if undo.Date != "" {
+		date, err = time.Parse("2006-01-02 15:04", undo.Date)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("invalid date %q: %s", undo.Date, err),
+			})
+			return
+		}
+	}
+
+	profile, err := profileFromRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	_, err = profile.CardByID(undo.ID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": fmt.Sprintf("couldn't get card: %s", err),
+		})
+		return
+	}
+
+	removed, err := profile.RemoveCompletion(undo.ID, date)
+	if errors.Is(err, sergeant.ErrNoCompletion) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("error removing completion from card %q: %s", undo.ID, err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, completionToJSON(removed))
+}
+
+func handlerCardCompletionsList(c *gin.Context) {
+	id, exists := c.GetQuery("id")
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "please specify an id query parameter",
+		})
+		return
+	}
+
+	profile, err := profileFromRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	card, err := profile.CardByID(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("couldn't get card: %s", err),
+		})
+		return
+	}
+
+	completions := []CompletionJSON{}
+	for _, completion := range card.History() {
+		completions = append(completions, completionToJSON(completion))
+	}
+
+	c.JSON(http.StatusOK, completions)
+}
+
+func handlerCardCompletionsAmend(c *gin.Context) {
+	amend := &CardAmendJSON{}
+
+	err := c.BindJSON(amend)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("couldn't decode put request: %s", err),
+		})
+		return
+	}
+
+	if amend.ID == "" || amend.Date == "" || amend.Answer == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "couldn't decode put request: some fields are blank",
+		})
+		return
+	}
+
+	if amend.Answer != "perfect" && amend.Answer != "minor" && amend.Answer != "major" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid answer field %q: please use 'perfect', 'minor' or 'major'", amend.Answer),
+		})
+		return
+	}
+
+	date, err := time.Parse("2006-01-02 15:04", amend.Date)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid date %q: %s", amend.Date, err),
+		})
+		return
+	}
+
+	profile, err := profileFromRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	_, err = profile.CardByID(amend.ID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": fmt.Sprintf("couldn't get card: %s", err),
+		})
+		return
+	}
+
+	amended, err := profile.AmendCompletion(amend.ID, date, amend.Answer, sergeant.Completion{
+		Duration: time.Millisecond * time.Duration(amend.Duration),
+	})
+	if errors.Is(err, sergeant.ErrNoCompletion) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("error amending completion on card %q: %s", amend.ID, err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, completionToJSON(amended))
+}
+
+func handlerCardStats(c *gin.Context) {
+	id, exists := c.GetQuery("id")
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "please specify an id query parameter",
+		})
+		return
+	}
+
+	profile, err := profileFromRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	card, err := profile.CardByID(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("couldn't get card: %s", err),
+		})
+		return
+	}
+
+	stats, err := profile.CardStats(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("couldn't get card stats: %s", err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, cardStatsToJSON(card, stats))
+}
+
+func handlerCardEdit(c *gin.Context) {
+	id := c.Param("id")
+	edit := &CardEditJSON{}
+
+	err := c.BindJSON(edit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("couldn't decode patch request: %s", err),
+		})
+		return
+	}
+
+	// Tags are changed first, since moving the card changes its path.
+	if edit.Tags != nil {
+		err = store.SetTags(id, *edit.Tags)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("couldn't change the tags of card %q: %s", id, err),
+			})
+			return
+		}
+	}
+
+	if edit.Path != nil {
+		_, err = store.MoveCard(id, *edit.Path)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("couldn't move card %q: %s", id, err),
+			})
+			return
+		}
+	}
+
+	respondCard(c, id)
+}
+
+func handlerCardEditQuestion(c *gin.Context) {
+	respondImageReplace(c, "question", store.ReplaceQuestionImages)
+}
+
+func handlerCardEditAnswer(c *gin.Context) {
+	respondImageReplace(c, "answer", store.ReplaceAnswerImages)
+}
+
+func handlerCardAlternativeAdd(c *gin.Context) {
+	respondImageReplace(c, "alternative", store.AddAlternative)
+}
+
+func handlerCardAlternativeRemove(c *gin.Context) {
+	id := c.Param("id")
+
+	nStr, exists := c.GetQuery("n")
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "please specify which alternative to remove with the n query parameter",
+		})
+		return
+	}
+
+	n, err := strconv.Atoi(nStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid alternative %q: %s", nStr, err),
+		})
+		return
+	}
+
+	err = store.RemoveAlternative(id, n)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("couldn't remove alternative %d of card %q: %s", n, id, err),
+		})
+		return
+	}
+
+	respondCard(c, id)
+}
+
+func handlerCardQuestionImage(c *gin.Context) {
+	respondImage(c, "question", func(card *sergeant.Card, alternative int) ([]string, bool) {
+		return card.QuestionPaths, alternative == 0
+	})
+}
+
+func handlerCardAnswerImage(c *gin.Context) {
+	respondImage(c, "answer", func(card *sergeant.Card, alternative int) ([]string, bool) {
+		if alternative == 0 {
+			return card.AnswerPaths, true
+		}
+
+		if alternative > len(card.Alternatives) {
+			return nil, false
+		}
+
+		return card.Alternatives[alternative-1], true
+	})
+}
+
+// respondImage responds with one page of the images of a card, found using imagePaths. The page query parameter picks
+// the page, counting from 1, and the alternative query parameter picks an alternative solution instead of the card's
+// own answer. imagePaths returns false if the alternative doesn't exist. If the width query parameter is given, the
+// image is scaled down to that width.
+// Responses have an ETag and Last-Modified header so that clients only download an image again once it's changed. If
+// the v query parameter is given, as it is in the URLs from cardToJSON, the image can be cached indefinitely since the
+// URL changes when the image does.
+func respondImage(c *gin.Context, name string, imagePaths func(card *sergeant.Card, alternative int) ([]string, bool)) {
+	id := c.Param("id")
+
+	card, err := store.CardByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": fmt.Sprintf("couldn't get card: %s", err),
+		})
+		return
+	}
+
+	page, alternative := 1, 0
+
+	for param, value := range map[string]*int{"page": &page, "alternative": &alternative} {
+		str, exists := c.GetQuery(param)
+		if !exists {
+			continue
+		}
+
+		*value, err = strconv.Atoi(str)
+		if err != nil || *value < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("invalid %s %q: must be a whole number above 0", param, str),
+			})
+			return
+		}
+	}
+
+	paths, ok := imagePaths(card, alternative)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": fmt.Sprintf("card %q has no alternative %d for its %s", id, alternative, name),
+		})
+		return
+	}
+
+	if page > len(paths) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": fmt.Sprintf("card %q has no page %d of its %s", id, page, name),
+		})
+		return
+	}
+
+	path := paths[page-1]
+
+	// The caching headers come from the image itself rather than from any thumbnail of it, since a thumbnail's
+	// modification time changes whenever it's used.
+	source, err := os.Stat(path)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("couldn't open the %s image of card %q: %s", name, id, err),
+		})
+		return
+	}
+
+	etag := fmt.Sprintf("%s-%s", strconv.FormatInt(source.ModTime().UnixNano(), 36), strconv.FormatInt(source.Size(), 36))
+
+	if widthStr, exists := c.GetQuery("width"); exists {
+		width, err := strconv.Atoi(widthStr)
+		if err != nil || width <= 0 || width > sergeant.MaxThumbnailWidth {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("invalid width %q: must be a whole number between 1 and %d", widthStr, sergeant.MaxThumbnailWidth),
+			})
+			return
+		}
+
+		etag += "-" + strconv.Itoa(width)
+
+		path, err = store.Thumbnail(path, width)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": fmt.Sprintf("couldn't scale down the %s image of card %q: %s", name, id, err),
+			})
+			return
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("couldn't open the %s image of card %q: %s", name, id, err),
+		})
+		return
+	}
+	defer file.Close()
+
+	c.Header("ETag", `"`+etag+`"`)
+
+	if _, versioned := c.GetQuery("v"); versioned {
+		c.Header("Cache-Control", "private, max-age=31536000, immutable")
+	} else {
+		c.Header("Cache-Control", "private, no-cache")
+	}
+
+	// SVGs can contain scripts, so images are sandboxed in case one is opened directly rather than in an <img> tag.
+	// PDFs aren't, since browsers refuse to show sandboxed PDFs.
+	c.Header("X-Content-Type-Options", "nosniff")
+	if sergeant.MediaType(path) != "application/pdf" {
+		c.Header("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; sandbox")
+	}
+
+	// ServeContent handles If-None-Match and If-Modified-Since, and works out the Content-Type from the extension.
+	http.ServeContent(c.Writer, c.Request, filepath.Base(path), source.ModTime(), file)
+}
+
+// respondImageReplace saves the images uploaded in the "image" field of a multipart form, passes them to replace along
+// with the card ID and responds with the updated card. Several images can be uploaded at once, and they're used as
+// the pages in the order they were given.
+func respondImageReplace(c *gin.Context, name string, replace func(id string, imagePaths []string) error) {
+	id := c.Param("id")
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("please upload the new %s images in the image field: %s", name, err),
+		})
+		return
+	}
+
+	if len(form.File["image"]) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("please upload the new %s images in the image field", name),
+		})
+		return
+	}
+
+	dir, err := ioutil.TempDir("", "sergeant-upload-")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("couldn't create temporary directory: %s", err),
+		})
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	imagePaths := []string{}
+
+	for i, file := range form.File["image"] {
+		// Only the extension of the uploaded file's name is kept, since it's used to name the attachment.
+		imagePath := filepath.Join(dir, fmt.Sprintf("%s-%d%s", name, i+1, filepath.Ext(file.Filename)))
+
+		err = c.SaveUploadedFile(file, imagePath)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": fmt.Sprintf("couldn't save uploaded %s image: %s", name, err),
+			})
+			return
+		}
+
+		imagePaths = append(imagePaths, imagePath)
+	}
+
+	err = replace(id, imagePaths)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("couldn't replace the %s images of card %q: %s", name, id, err),
+		})
+		return
+	}
+
+	respondCard(c, id)
+}
+
+// respondCard responds with the JSON representation of the card with the given ID.
+func respondCard(c *gin.Context, id string) {
+	card, err := store.CardByID(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("couldn't get card: %s", err),
+		})
+		return
+	}
+
+	cardJSON, err := cardToJSON(card)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("couldn't turn card into JSON: %s", err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, cardJSON)
+}
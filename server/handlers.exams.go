@@ -0,0 +1,215 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/albatross-org/sergeant"
+	"github.com/gin-gonic/gin"
+)
+
+func handlerExamsStart(c *gin.Context) {
+	setConfig, err := setConfigFromRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	viewName, exists := c.GetQuery("viewName")
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "please specify a viewName query parameter",
+		})
+		return
+	}
+
+	setName, exists := c.GetQuery("setName")
+	if !exists {
+		setName = "all"
+	}
+
+	questionsStr, exists := c.GetQuery("questions")
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "please specify a questions query parameter",
+		})
+		return
+	}
+
+	questions, err := strconv.Atoi(questionsStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("couldn't parse questions %q: %s", questionsStr, err),
+		})
+		return
+	}
+
+	var timeLimit time.Duration
+
+	if timeLimitStr, exists := c.GetQuery("timeLimit"); exists {
+		timeLimit, err = time.ParseDuration(timeLimitStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("couldn't parse timeLimit %q: %s", timeLimitStr, err),
+			})
+			return
+		}
+	}
+
+	profile, err := profileFromRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	exam, err := profile.StartExam(setName, setConfig, viewName, questions, timeLimit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("couldn't start exam: %s", err),
+		})
+		return
+	}
+
+	respondExamNext(c, exam.ID)
+}
+
+func handlerExamsNext(c *gin.Context) {
+	id, exists := c.GetQuery("id")
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "please specify an id query parameter",
+		})
+		return
+	}
+
+	respondExamNext(c, id)
+}
+
+func handlerExamsAnswer(c *gin.Context) {
+	answer := &ExamAnswerJSON{}
+
+	err := c.BindJSON(answer)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("couldn't decode put request: %s", err),
+		})
+		return
+	}
+
+	if answer.ID == "" || answer.Answer == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "couldn't decode put request: some fields are blank",
+		})
+		return
+	}
+
+	_, err = store.ExamAnswer(answer.ID, answer.Answer, time.Millisecond*time.Duration(answer.Duration))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("couldn't answer question in exam %q: %s", answer.ID, err),
+		})
+		return
+	}
+
+	respondExamNext(c, answer.ID)
+}
+
+func handlerExamsSubmit(c *gin.Context) {
+	submit := &ExamSubmitJSON{}
+
+	err := c.BindJSON(submit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("couldn't decode post request: %s", err),
+		})
+		return
+	}
+
+	exam, err := store.SubmitExam(submit.ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("couldn't submit exam %q: %s", submit.ID, err),
+		})
+		return
+	}
+
+	respondExam(c, exam, nil)
+}
+
+func handlerExamsGet(c *gin.Context) {
+	id, exists := c.GetQuery("id")
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "please specify an id query parameter",
+		})
+		return
+	}
+
+	exam, err := store.Exam(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondExam(c, exam, nil)
+}
+
+func handlerExamsList(c *gin.Context) {
+	exams, err := store.Exams()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("couldn't list exams: %s", err),
+		})
+		return
+	}
+
+	list := []ExamJSON{}
+	for _, exam := range exams {
+		examJSON, err := examToJSON(exam, nil)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": fmt.Sprintf("couldn't turn exam into JSON: %s", err),
+			})
+			return
+		}
+
+		list = append(list, examJSON)
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// respondExamNext responds with an exam and the card for the question it is currently showing. Once every question
+// has been answered or the time has run out, the exam is returned without a card so the client knows to submit it.
+func respondExamNext(c *gin.Context, id string) {
+	exam, card, err := store.ExamNext(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondExam(c, exam, card)
+}
+
+// respondExam responds with the JSON representation of an exam and the card it's showing, which can be nil.
+func respondExam(c *gin.Context, exam *sergeant.Exam, card *sergeant.Card) {
+	examJSON, err := examToJSON(exam, card)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("couldn't turn exam into JSON: %s", err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, examJSON)
+}
@@ -0,0 +1,20 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func handlerProfilesList(c *gin.Context) {
+	profiles, err := store.Profiles()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("couldn't list profiles: %s", err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, profiles)
+}
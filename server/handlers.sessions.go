@@ -0,0 +1,221 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/albatross-org/sergeant"
+	"github.com/gin-gonic/gin"
+)
+
+func handlerSessionsStart(c *gin.Context) {
+	setConfig, err := setConfigFromRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	viewName, exists := c.GetQuery("viewName")
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "please specify a viewName query parameter",
+		})
+		return
+	}
+
+	setName, exists := c.GetQuery("setName")
+	if !exists {
+		setName = "all"
+	}
+
+	profile, err := profileFromRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	session, err := profile.StartSession(setName, setConfig, viewName)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("couldn't start session: %s", err),
+		})
+		return
+	}
+
+	respondSessionNext(c, session.ID)
+}
+
+func handlerSessionsNext(c *gin.Context) {
+	id, exists := c.GetQuery("id")
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "please specify an id query parameter",
+		})
+		return
+	}
+
+	respondSessionNext(c, id)
+}
+
+func handlerSessionsAnswer(c *gin.Context) {
+	answer := &SessionAnswerJSON{}
+
+	err := c.BindJSON(answer)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("couldn't decode put request: %s", err),
+		})
+		return
+	}
+
+	if answer.ID == "" || answer.Answer == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "couldn't decode put request: some fields are blank",
+		})
+		return
+	}
+
+	_, err = store.SessionAnswer(answer.ID, answer.Answer, time.Millisecond*time.Duration(answer.Duration))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("couldn't answer card in session %q: %s", answer.ID, err),
+		})
+		return
+	}
+
+	respondSessionNext(c, answer.ID)
+}
+
+func handlerSessionsSkip(c *gin.Context) {
+	skip := &SessionAnswerJSON{}
+
+	err := c.BindJSON(skip)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("couldn't decode put request: %s", err),
+		})
+		return
+	}
+
+	if skip.ID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "couldn't decode put request: the id field is blank",
+		})
+		return
+	}
+
+	_, err = store.SessionAnswer(skip.ID, "skip", time.Millisecond*time.Duration(skip.Duration))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("couldn't skip card in session %q: %s", skip.ID, err),
+		})
+		return
+	}
+
+	respondSessionNext(c, skip.ID)
+}
+
+func handlerSessionsEnd(c *gin.Context) {
+	end := &SessionEndJSON{}
+
+	err := c.BindJSON(end)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("couldn't decode post request: %s", err),
+		})
+		return
+	}
+
+	session, err := store.EndSession(end.ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("couldn't end session %q: %s", end.ID, err),
+		})
+		return
+	}
+
+	respondSession(c, session, nil)
+}
+
+func handlerSessionsGet(c *gin.Context) {
+	id, exists := c.GetQuery("id")
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "please specify an id query parameter",
+		})
+		return
+	}
+
+	session, err := store.Session(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respondSession(c, session, nil)
+}
+
+func handlerSessionsList(c *gin.Context) {
+	sessions, err := store.Sessions()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("couldn't list sessions: %s", err),
+		})
+		return
+	}
+
+	list := []SessionJSON{}
+	for _, session := range sessions {
+		sessionJSON, err := sessionToJSON(session, nil)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": fmt.Sprintf("couldn't turn session into JSON: %s", err),
+			})
+			return
+		}
+
+		list = append(list, sessionJSON)
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// respondSessionNext responds with a session and the card it is currently showing, picking a new card if needed.
+func respondSessionNext(c *gin.Context, id string) {
+	session, card, err := store.SessionNext(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if card == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("there's no cards left in the %q set", session.SetName),
+		})
+		return
+	}
+
+	respondSession(c, session, card)
+}
+
+// respondSession responds with the JSON representation of a session and the card it's showing, which can be nil.
+func respondSession(c *gin.Context, session *sergeant.Session, card *sergeant.Card) {
+	sessionJSON, err := sessionToJSON(session, card)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("couldn't turn session into JSON: %s", err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, sessionJSON)
+}
@@ -1,8 +1,10 @@
 package server
 
 import (
+	"bytes"
 	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/albatross-org/sergeant"
 	"github.com/gin-gonic/gin"
@@ -17,6 +19,14 @@ func handlerSetsGet(c *gin.Context) {
 		return
 	}
 
+	profile, err := profileFromRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
 	viewName, exists := c.GetQuery("viewName")
 	if !exists {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -25,7 +35,7 @@ func handlerSetsGet(c *gin.Context) {
 		return
 	}
 
-	view := sergeant.DefaultViews[viewName]
+	view := store.Views[viewName]
 	if view == nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": fmt.Sprintf("the view %q doesn't exist", viewName),
@@ -33,7 +43,7 @@ func handlerSetsGet(c *gin.Context) {
 		return
 	}
 
-	set, _, err := store.SetFromConfig(setConfig)
+	set, _, err := profile.SetFromConfig(setConfig)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": fmt.Sprintf("error loading set %q: %s", setConfig.Name, err),
@@ -48,9 +58,26 @@ func handlerSetsGet(c *gin.Context) {
 		return
 	}
 
-	fmt.Println(view, viewName)
+	// Recently served cards are remembered per profile, set and view, so that skipping a card doesn't bring it straight
+	// back.
+	key := profile.Name + "/" + c.DefaultQuery("setName", "all") + "/" + viewName
+
+	// Giving a seed means the same cards come up in the same order for anyone using it, so seeded requests are
+	// remembered separately.
+	if seedStr, exists := c.GetQuery("seed"); exists {
+		seed, err := strconv.ParseInt(seedStr, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("couldn't parse seed %q: %s", seedStr, err),
+			})
+			return
+		}
 
-	card := view.Next(set)
+		view = sergeant.SeedView(view, seed)
+		key += "/" + seedStr
+	}
+
+	card := store.Next(key, view, set)
 	if card == nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": fmt.Sprintf("couldn't get a card from the %q view", viewName),
@@ -82,7 +109,15 @@ func handlerSetsStats(c *gin.Context) {
 		return
 	}
 
-	set, _, err := store.SetFromConfig(setConfig)
+	profile, err := profileFromRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	set, _, err := profile.SetFromConfig(setConfig)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": fmt.Sprintf("error loading set %q: %s", setConfig.Name, err),
@@ -92,3 +127,144 @@ func handlerSetsStats(c *gin.Context) {
 
 	c.JSON(http.StatusOK, getSetHeatmapJSON(set))
 }
+
+func handlerSetsExport(c *gin.Context) {
+	setConfig, err := setConfigFromRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	profile, err := profileFromRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	set, _, err := profile.SetFromConfig(setConfig)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("error loading set %q: %s", setConfig.Name, err),
+		})
+		return
+	}
+
+	if len(set.Cards) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("there's no cards in the %q set", setConfig.Name),
+		})
+		return
+	}
+
+	// The PDF is written to a buffer first so that an error can still be reported as JSON.
+	var buf bytes.Buffer
+
+	err = sergeant.ExportPDF(&buf, setConfig.Name, set.Cards)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("couldn't export set %q: %s", setConfig.Name, err),
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", c.DefaultQuery("setName", "all")+".pdf"))
+	c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
+
+func handlerSetsTree(c *gin.Context) {
+	setConfig, err := setConfigFromRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	profile, err := profileFromRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	set, _, err := profile.SetFromConfig(setConfig)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("error loading set %q: %s", setConfig.Name, err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, setTreeToJSON(sergeant.NewViewDifficulties(0).Tree(set)))
+}
+
+func handlerSetsCreate(c *gin.Context) {
+	respondSetChange(c, store.CreateSet)
+}
+
+func handlerSetsUpdate(c *gin.Context) {
+	respondSetChange(c, store.UpdateSet)
+}
+
+func handlerSetsDelete(c *gin.Context) {
+	setName, exists := c.GetQuery("setName")
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "please specify a setName query parameter",
+		})
+		return
+	}
+
+	err := store.DeleteSet(setName)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("couldn't delete set %q: %s", setName, err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, getSetListJSON())
+}
+
+// respondSetChange reads the definition of a set from the body of a request, passes it to change along with the
+// setName query parameter and responds with the resulting set.
+func respondSetChange(c *gin.Context, change func(name string, set sergeant.ConfigSet) (sergeant.ConfigSet, error)) {
+	setName, exists := c.GetQuery("setName")
+	if !exists {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "please specify a setName query parameter",
+		})
+		return
+	}
+
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("couldn't read request body: %s", err),
+		})
+		return
+	}
+
+	setConfig, err := sergeant.ParseConfigSet(body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid set %q: %s", setName, err),
+		})
+		return
+	}
+
+	setConfig, err = change(setName, setConfig)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("couldn't save set %q: %s", setName, err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, setToJSON(setName, setConfig))
+}
@@ -1,16 +1,33 @@
 package server
 
 import (
+	"net/http"
+
 	"github.com/gin-gonic/gin"
 )
 
-// corsMiddleware allows Cross-Origin Resource Sharing to work.
+// corsMiddleware allows Cross-Origin Resource Sharing to work for the origins in the allowed-origins of the config.
+// Requests from other origins get no CORS headers, so browsers only let pages served by sergeant itself use the API.
+// If "*" is allowed, pages from any other origin can use the API too, but browsers won't send them the login cookie.
 func corsMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT")
+		c.Writer.Header().Add("Vary", "Origin")
+
+		origin := c.GetHeader("Origin")
+		listed := origin != "" && originAllowed(origin)
+		wildcard := origin != "" && !listed && anyOriginAllowed()
+
+		if listed {
+			c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
+			c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
+		} else if wildcard {
+			c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+		}
+
+		if listed || wildcard {
+			c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+			c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, PATCH, DELETE")
+		}
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -21,6 +38,21 @@ func corsMiddleware() gin.HandlerFunc {
 	}
 }
 
+// authMiddleware stops requests that haven't logged in from reaching the handlers after it, if authentication is
+// turned on in the config.
+func authMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !authenticated(c) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "please log in first",
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
 func initMiddleware() {
 	router.Use(corsMiddleware())
 }
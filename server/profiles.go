@@ -0,0 +1,19 @@
+package server
+
+import (
+	"github.com/albatross-org/sergeant"
+	"github.com/gin-gonic/gin"
+)
+
+// profileFromRequest returns the profile selected by the profile query parameter. If it isn't given, the default
+// profile is used. It will return an error if the profile name isn't valid.
+func profileFromRequest(c *gin.Context) (*sergeant.Profile, error) {
+	name := c.Query("profile")
+
+	err := sergeant.ValidateProfileName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return store.Profile(name), nil
+}
@@ -1,26 +1,40 @@
 package server
 
-import (
-	"github.com/gin-gonic/contrib/static"
-	"github.com/gin-gonic/gin"
-)
-
-func initRoutes() {
+func initRoutes() error {
 	// Serve the web UI at the base path.
-	router.Use(
-		static.Serve("/", static.LocalFile("./public/build", true)),
-	)
+	err := initUI()
+	if err != nil {
+		return err
+	}
 
-	router.NoRoute(func(c *gin.Context) {
-		c.File("./public/build/index.html")
-	})
+	// Routes that change anything need logging in to if authentication is turned on in the config. Reading cards and
+	// stats doesn't.
+	auth := authMiddleware()
 
 	// Set up basic routes for the V1 api.
-	api := router.Group("/api/v1")
+	api := router.Group(options.BasePath + "/api/v1")
 	{
+		authGroup := api.Group("/auth")
+		{
+			authGroup.POST("/login", handlerAuthLogin)
+			authGroup.POST("/logout", handlerAuthLogout)
+			authGroup.GET("/status", handlerAuthStatus)
+		}
+
 		cards := api.Group("/cards")
 		{
-			cards.PUT("/update", handlerCardUpdate)
+			cards.PUT("/update", auth, handlerCardUpdate)
+			cards.POST("/undo", auth, handlerCardUndo)
+			cards.GET("/completions", handlerCardCompletionsList)
+			cards.PUT("/completions", auth, handlerCardCompletionsAmend)
+			cards.GET("/stats", handlerCardStats)
+			cards.GET("/images/:id/question", handlerCardQuestionImage)
+			cards.GET("/images/:id/answer", handlerCardAnswerImage)
+			cards.PATCH("/:id", auth, handlerCardEdit)
+			cards.PATCH("/:id/question", auth, handlerCardEditQuestion)
+			cards.PATCH("/:id/answer", auth, handlerCardEditAnswer)
+			cards.POST("/alternatives/:id", auth, handlerCardAlternativeAdd)
+			cards.DELETE("/alternatives/:id", auth, handlerCardAlternativeRemove)
 		}
 
 		sets := api.Group("/sets")
@@ -28,7 +42,36 @@ func initRoutes() {
 			sets.GET("/get", handlerSetsGet)
 			sets.GET("/list", handlerSetsList)
 			sets.GET("/stats", handlerSetsStats)
+			sets.GET("/export", handlerSetsExport)
+			sets.GET("/tree", handlerSetsTree)
+			sets.POST("", auth, handlerSetsCreate)
+			sets.PUT("", auth, handlerSetsUpdate)
+			sets.DELETE("", auth, handlerSetsDelete)
+		}
+
+		api.GET("/profiles", handlerProfilesList)
+
+		sessions := api.Group("/sessions")
+		{
+			sessions.GET("", handlerSessionsList)
+			sessions.GET("/get", handlerSessionsGet)
+			sessions.POST("/start", auth, handlerSessionsStart)
+			sessions.GET("/next", handlerSessionsNext)
+			sessions.PUT("/answer", auth, handlerSessionsAnswer)
+			sessions.PUT("/skip", auth, handlerSessionsSkip)
+			sessions.POST("/end", auth, handlerSessionsEnd)
+		}
+
+		exams := api.Group("/exams")
+		{
+			exams.GET("", handlerExamsList)
+			exams.GET("/get", handlerExamsGet)
+			exams.POST("/start", auth, handlerExamsStart)
+			exams.GET("/next", handlerExamsNext)
+			exams.PUT("/answer", auth, handlerExamsAnswer)
+			exams.POST("/submit", auth, handlerExamsSubmit)
 		}
 	}
 
+	return nil
 }
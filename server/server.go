@@ -1,20 +1,68 @@
 package server
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/albatross-org/sergeant"
 	"github.com/gin-gonic/gin"
 )
 
 var router *gin.Engine
 var store *sergeant.Store
+var activeLogins *logins
+var loginAttempts *loginThrottle
+var options Options
+
+// Options controls where and how the server is run.
+type Options struct {
+	// Addr is the address to listen on. If it's blank, ":8080" is used.
+	Addr string
+
+	// BasePath is the path that the web UI and API are served under, like "/sergeant", so that the server can be put
+	// behind a reverse proxy alongside other things. If it's blank, they're served at the root.
+	BasePath string
+
+	// UIDir is a directory to serve the web UI from instead of the one built into the binary, such as
+	// "./public/build" while working on the UI.
+	UIDir string
+
+	// CertFile and KeyFile are the TLS certificate and key to serve HTTPS with. If they're blank, plain HTTP is used.
+	CertFile string
+	KeyFile  string
+}
+
+// Run starts the server, only returning if it couldn't be started or stops.
+func Run(s *sergeant.Store, o Options) error {
+	if (o.CertFile == "") != (o.KeyFile == "") {
+		return fmt.Errorf("both a TLS certificate and key are needed to use HTTPS")
+	}
+
+	if o.Addr == "" {
+		o.Addr = ":8080"
+	}
+
+	o.BasePath = strings.TrimSuffix(o.BasePath, "/")
+	if o.BasePath != "" && !strings.HasPrefix(o.BasePath, "/") {
+		o.BasePath = "/" + o.BasePath
+	}
 
-// Run starts the server.
-func Run(s *sergeant.Store) {
 	router = gin.Default()
 	store = s
+	activeLogins = newLogins()
+	loginAttempts = newLoginThrottle()
+	options = o
 
 	initMiddleware()
-	initRoutes()
 
-	router.Run()
+	err := initRoutes()
+	if err != nil {
+		return err
+	}
+
+	if o.CertFile != "" {
+		return router.RunTLS(o.Addr, o.CertFile, o.KeyFile)
+	}
+
+	return router.Run(o.Addr)
 }
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"time"
+
+	"github.com/albatross-org/sergeant"
+)
+
+// SessionJSON is the response returned when a client asks about a study session.
+type SessionJSON struct {
+	ID       string `json:"id"`
+	SetName  string `json:"setName"`
+	ViewName string `json:"viewName"`
+	Profile  string `json:"profile,omitempty"`
+
+	Started string `json:"started"`
+	Ended   string `json:"ended,omitempty"`
+
+	// Card is the card currently being shown, if there is one.
+	Card *CardJSON `json:"card,omitempty"`
+
+	// CardElapsed is how long the current card has been shown for in milliseconds. This lets the client carry on
+	// timing a card after the page is refreshed.
+	CardElapsed int `json:"cardElapsed"`
+
+	// Elapsed is the total time spent on answered and skipped cards, in milliseconds.
+	Elapsed int `json:"elapsed"`
+
+	Perfect int `json:"perfect"`
+	Minor   int `json:"minor"`
+	Major   int `json:"major"`
+	Skipped int `json:"skipped"`
+
+	Results []SessionResultJSON `json:"results"`
+}
+
+// SessionResultJSON is the JSON representation of the outcome of a single card in a session.
+type SessionResultJSON struct {
+	ID       string `json:"id"`
+	Path     string `json:"path"`
+	Answer   string `json:"answer"`
+	Date     string `json:"date"`
+	Duration int    `json:"duration"`
+}
+
+// sessionToJSON converts a *sergeant.Session and the card it's currently showing into the JSON format ready to be
+// accepted by the client. The card can be nil.
+// If an error is returned, it's due to an issue with converting the card's contents to a data URI.
+func sessionToJSON(session *sergeant.Session, card *sergeant.Card) (SessionJSON, error) {
+	sessionJSON := SessionJSON{
+		ID:       session.ID,
+		SetName:  session.SetName,
+		ViewName: session.ViewName,
+		Profile:  session.Profile,
+		Started:  session.Started.Format("2006-01-02 15:04"),
+		Elapsed:  int(session.Elapsed / time.Millisecond),
+		Perfect:  session.Count("perfect"),
+		Minor:    session.Count("minor"),
+		Major:    session.Count("major"),
+		Skipped:  session.Count("skip"),
+		Results:  []SessionResultJSON{},
+	}
+
+	if session.Finished() {
+		sessionJSON.Ended = session.Ended.Format("2006-01-02 15:04")
+	}
+
+	if card != nil {
+		cardJSON, err := cardToJSON(card)
+		if err != nil {
+			return SessionJSON{}, err
+		}
+
+		sessionJSON.Card = &cardJSON
+		sessionJSON.CardElapsed = int(time.Since(session.CurrentServed) / time.Millisecond)
+	}
+
+	for _, result := range session.Results {
+		sessionJSON.Results = append(sessionJSON.Results, SessionResultJSON{
+			ID:       result.CardID,
+			Path:     result.Path,
+			Answer:   result.Answer,
+			Date:     result.Date.Format("2006-01-02 15:04"),
+			Duration: int(result.Duration / time.Millisecond),
+		})
+	}
+
+	return sessionJSON, nil
+}
+
+// SessionAnswerJSON is what is sent to the server when a client answers or skips the current card in a session.
+// Answer is ignored when skipping. If Duration is zero, the time since the card was first shown is used.
+type SessionAnswerJSON struct {
+	ID       string `json:"id"`
+	Answer   string `json:"answer"`
+	Duration int    `json:"duration"`
+}
+
+// SessionEndJSON is what is sent to the server when a client wants to end a session.
+type SessionEndJSON struct {
+	ID string `json:"id"`
+}
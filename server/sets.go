@@ -86,7 +86,7 @@ func setToJSON(name string, set sergeant.ConfigSet) SetJSON {
 func getSetListJSON() SetListJSON {
 	response := SetListJSON{}
 
-	for name, set := range store.Sets {
+	for name, set := range store.ConfigSets() {
 		response = append(response, setToJSON(name, set))
 	}
 
@@ -124,7 +124,7 @@ func setConfigFromRequest(c *gin.Context) (sergeant.ConfigSet, error) {
 		name = "all"
 	}
 
-	existingConfig, exists := store.Sets[name]
+	existingConfig, exists := store.ConfigSet(name)
 	if !exists {
 		return sergeant.ConfigSet{}, fmt.Errorf("The existing set %q doesn't exist", name)
 	}
@@ -165,9 +165,33 @@ func setConfigFromRequest(c *gin.Context) (sergeant.ConfigSet, error) {
 		config.TagsAnd = append(config.TagsOr, tagsAnd...)
 	}
 
+	excludePaths, exists := c.GetQueryArray("setExcludePaths")
+	if exists {
+		config.ExcludePaths = append(config.ExcludePaths, excludePaths...)
+	}
+
+	excludeTags, exists := c.GetQueryArray("setExcludeTags")
+	if exists {
+		config.ExcludeTags = append(config.ExcludeTags, excludeTags...)
+	}
+
+	query, exists := c.GetQuery("setQuery")
+	if exists && query != "" {
+		_, err = sergeant.ParseFilter(query)
+		if err != nil {
+			return sergeant.ConfigSet{}, fmt.Errorf("Invalid query %q specified: %w", query, err)
+		}
+
+		if config.Query != "" {
+			config.Query = "(" + config.Query + ") AND (" + query + ")"
+		} else {
+			config.Query = query
+		}
+	}
+
 	rawBeforeDuration, exists := c.GetQuery("setBeforeDuration")
 	if exists {
-		config.BeforeDuration, err = time.ParseDuration(rawBeforeDuration)
+		config.BeforeDuration, err = sergeant.ParseDuration(rawBeforeDuration)
 		if err != nil {
 			return sergeant.ConfigSet{}, fmt.Errorf("Invalid before duration %q specified: %w", rawBeforeDuration, err)
 		}
@@ -175,7 +199,7 @@ func setConfigFromRequest(c *gin.Context) (sergeant.ConfigSet, error) {
 
 	rawAfterDuration, exists := c.GetQuery("setAfterDuration")
 	if exists {
-		config.AfterDuration, err = time.ParseDuration(rawAfterDuration)
+		config.AfterDuration, err = sergeant.ParseDuration(rawAfterDuration)
 		if err != nil {
 			return sergeant.ConfigSet{}, fmt.Errorf("Invalid after duration %q specified: %w", rawAfterDuration, err)
 		}
@@ -262,3 +286,38 @@ func getSetHeatmapJSON(set *sergeant.Set) []SetHeatmapJSON {
 
 	return list
 }
+
+// SetTreeJSON is a category in the tree of paths in a set, along with how well the cards in it have been answered.
+type SetTreeJSON struct {
+	Name       string        `json:"name"`
+	Path       string        `json:"path"`
+	Perfect    int           `json:"perfect"`
+	Minor      int           `json:"minor"`
+	Major      int           `json:"major"`
+	Difficulty float64       `json:"difficulty"`
+	Cards      int           `json:"cards"`
+	Unseen     int           `json:"unseen"`
+	Children   []SetTreeJSON `json:"children"`
+}
+
+// setTreeToJSON converts the nodes of a tree returned by (*sergeant.Difficulties).Tree into the JSON format ready to
+// be accepted by the client.
+func setTreeToJSON(nodes []*sergeant.TreeNode) []SetTreeJSON {
+	tree := []SetTreeJSON{}
+
+	for _, node := range nodes {
+		tree = append(tree, SetTreeJSON{
+			Name:       node.Name,
+			Path:       node.Path,
+			Perfect:    node.Perfect,
+			Minor:      node.Minor,
+			Major:      node.Major,
+			Difficulty: node.Difficulty,
+			Cards:      node.Cards,
+			Unseen:     node.Unseen,
+			Children:   setTreeToJSON(node.Children),
+		})
+	}
+
+	return tree
+}
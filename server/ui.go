@@ -0,0 +1,117 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io/fs"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/albatross-org/sergeant/public"
+	"github.com/gin-gonic/contrib/static"
+	"github.com/gin-gonic/gin"
+)
+
+// uiFileSystem serves the web UI built into the binary using the static middleware.
+type uiFileSystem struct {
+	http.FileSystem
+}
+
+// Exists reports whether there is a file in the UI for the request path, once the prefix has been removed.
+func (ui uiFileSystem) Exists(prefix string, path string) bool {
+	name := strings.TrimPrefix(path, prefix)
+	if len(name) == len(path) && prefix != "" {
+		return false
+	}
+
+	file, err := ui.Open("/" + strings.TrimPrefix(name, "/"))
+	if err != nil {
+		return false
+	}
+
+	file.Close()
+	return true
+}
+
+// defaultUIDir is where the web UI is built to in the repository. If sergeant was built without the web UI and no
+// directory is given, it's served from here when sergeant is run from the root of the repository.
+const defaultUIDir = "./public/build"
+
+// initUI serves the web UI at the base path, either from options.UIDir or from the UI built into the binary, falling
+// back to defaultUIDir if neither is there. Any other route that doesn't exist gets the UI's index.html, so that the UI
+// can handle it instead.
+// If the web UI can't be found anywhere, only the API is served.
+func initUI() error {
+	prefix := options.BasePath
+	if prefix == "" {
+		prefix = "/"
+	}
+
+	ui, embedded := public.UI()
+
+	dir := options.UIDir
+	if dir == "" && !embedded {
+		if _, err := os.Stat(filepath.Join(defaultUIDir, "index.html")); err == nil {
+			dir = defaultUIDir
+		}
+	}
+
+	if dir != "" {
+		indexPath := filepath.Join(dir, "index.html")
+
+		if _, err := os.Stat(indexPath); err != nil {
+			return fmt.Errorf("couldn't find the web UI in %q: %w", dir, err)
+		}
+
+		router.Use(static.Serve(prefix, static.LocalFile(dir, true)))
+		router.NoRoute(func(c *gin.Context) {
+			// The index is read every time so that the UI can be rebuilt while the server is running.
+			index, err := ioutil.ReadFile(indexPath)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": fmt.Sprintf("couldn't read the web UI: %s", err),
+				})
+				return
+			}
+
+			c.Data(http.StatusOK, "text/html; charset=utf-8", withBase(index))
+		})
+
+		return nil
+	}
+
+	if !embedded {
+		router.NoRoute(func(c *gin.Context) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "couldn't find the web UI, please build it with the webui tag, serve it with --ui-dir or run sergeant from the root of the repository",
+			})
+		})
+
+		return nil
+	}
+
+	index, err := fs.ReadFile(ui, "index.html")
+	if err != nil {
+		return fmt.Errorf("couldn't read the web UI built into sergeant: %w", err)
+	}
+
+	index = withBase(index)
+
+	router.Use(static.Serve(prefix, uiFileSystem{http.FS(ui)}))
+	router.NoRoute(func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", index)
+	})
+
+	return nil
+}
+
+// withBase adds a <base> tag to the UI's index.html pointing at the base path. The UI is built with relative URLs,
+// so this is what its assets, routes and API calls are resolved against wherever the server is running.
+func withBase(index []byte) []byte {
+	base := fmt.Sprintf(`<head><base href="%s/">`, html.EscapeString(options.BasePath))
+	return bytes.Replace(index, []byte("<head>"), []byte(base), 1)
+}
@@ -0,0 +1,413 @@
+package sergeant
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// Session is a single sitting of studying cards from one set using one view.
+// Sessions are kept on the server rather than in the browser so that refreshing the page resumes the same question,
+// and are saved once they're finished so that they can be looked back on later.
+type Session struct {
+	ID string `json:"id"`
+
+	SetName  string    `json:"setName"`
+	Set      ConfigSet `json:"set"`
+	ViewName string    `json:"viewName"`
+
+	// Profile is the name of the profile studying, whose completions are used and added to.
+	Profile string `json:"profile,omitempty"`
+
+	Started time.Time `json:"started"`
+	Ended   time.Time `json:"ended,omitempty"`
+
+	// Current is the ID of the card currently being shown, or the empty string if a new card needs to be picked.
+	Current string `json:"current,omitempty"`
+
+	// CurrentServed is when the current card was first shown.
+	CurrentServed time.Time `json:"currentServed,omitempty"`
+
+	// Queue holds the IDs of cards that will be shown, in order, before the view is asked for any more. It's filled
+	// with sessionQueueLength cards from the view when the session starts and whenever it runs out.
+	Queue []string `json:"queue,omitempty"`
+
+	// Results is every card answered or skipped during the session, in the order they were seen.
+	Results []SessionResult `json:"results"`
+
+	// Elapsed is the total time spent on cards that have been answered or skipped.
+	Elapsed time.Duration `json:"elapsed"`
+}
+
+// sessionQueueLength is how many cards are picked at a time for a session's queue. It's kept short so that views
+// which take the answers given so far into account don't fall too far behind.
+const sessionQueueLength = 5
+
+// SessionResult is the outcome of showing a single card during a session.
+type SessionResult struct {
+	CardID string `json:"cardID"`
+	Path   string `json:"path"`
+
+	// Answer is either a completion type ("perfect", "minor" or "major") or "skip" if the card was skipped.
+	Answer   string        `json:"answer"`
+	Date     time.Time     `json:"date"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Finished reports whether the session has ended.
+func (session *Session) Finished() bool {
+	return !session.Ended.IsZero()
+}
+
+// Count returns how many cards in the session were given the answer specified, such as "perfect" or "skip".
+func (session *Session) Count(answer string) int {
+	count := 0
+	for _, result := range session.Results {
+		if result.Answer == answer {
+			count++
+		}
+	}
+
+	return count
+}
+
+// clone returns a copy of the session that can be read safely while the original continues to be modified.
+func (session *Session) clone() *Session {
+	clone := *session
+	clone.Queue = append([]string(nil), session.Queue...)
+	clone.Results = append([]SessionResult{}, session.Results...)
+
+	return &clone
+}
+
+// recordID returns the ID of the session, so that it can be kept in a jsonDirStore.
+func (session *Session) recordID() string {
+	return session.ID
+}
+
+// recordStarted returns when the session was started, so that it can be kept in a jsonDirStore.
+func (session *Session) recordStarted() time.Time {
+	return session.Started
+}
+
+// sessionStore keeps track of sessions and saves each one to its own JSON file in a directory whenever it changes.
+type sessionStore struct {
+	*jsonDirStore
+}
+
+// newSessionStore returns a new sessionStore that saves sessions in the directory given.
+func newSessionStore(path string) *sessionStore {
+	return &sessionStore{newJSONDirStore(path, "session", func() jsonRecord { return &Session{} })}
+}
+
+// get returns the session with the given ID, loading it from disk if it hasn't been seen yet.
+// The caller must hold the lock.
+func (sessions *sessionStore) get(id string) (*Session, error) {
+	record, err := sessions.jsonDirStore.get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return record.(*Session), nil
+}
+
+// save writes a session to disk. The caller must hold the lock.
+func (sessions *sessionStore) save(session *Session) error {
+	return sessions.jsonDirStore.save(session)
+}
+
+// list returns every saved session, most recently started first. The caller must hold the lock.
+func (sessions *sessionStore) list() ([]*Session, error) {
+	records, err := sessions.jsonDirStore.list()
+	if err != nil {
+		return nil, err
+	}
+
+	list := []*Session{}
+	for _, record := range records {
+		list = append(list, record.(*Session))
+	}
+
+	return list, nil
+}
+
+// StartSession starts a new session studying the set given using the view with the name given.
+// The set's config is pinned to the session, so later changes to the config don't affect sessions already started.
+func (store *Store) StartSession(setName string, set ConfigSet, viewName string) (*Session, error) {
+	return store.Profile(DefaultProfile).StartSession(setName, set, viewName)
+}
+
+// StartSession starts a new session for the profile, like (*Store).StartSession.
+func (profile *Profile) StartSession(setName string, set ConfigSet, viewName string) (*Session, error) {
+	store := profile.store
+
+	if store.Views[viewName] == nil {
+		return nil, fmt.Errorf("the view %q doesn't exist", viewName)
+	}
+
+	session := &Session{
+		ID:       newSessionID(),
+		SetName:  setName,
+		Set:      set,
+		ViewName: viewName,
+		Profile:  profile.Name,
+		Started:  time.Now(),
+		Results:  []SessionResult{},
+	}
+
+	err := store.fillQueue(session)
+	if err != nil {
+		return nil, err
+	}
+
+	store.sessions.mu.Lock()
+	defer store.sessions.mu.Unlock()
+
+	err = store.sessions.save(session)
+	if err != nil {
+		return nil, err
+	}
+
+	return session.clone(), nil
+}
+
+// Session returns the session with the given ID.
+func (store *Store) Session(id string) (*Session, error) {
+	store.sessions.mu.Lock()
+	defer store.sessions.mu.Unlock()
+
+	session, err := store.sessions.get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return session.clone(), nil
+}
+
+// Sessions returns every session, both finished and unfinished, most recently started first.
+func (store *Store) Sessions() ([]*Session, error) {
+	store.sessions.mu.Lock()
+	defer store.sessions.mu.Unlock()
+
+	list, err := store.sessions.list()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, session := range list {
+		list[i] = session.clone()
+	}
+
+	return list, nil
+}
+
+// SessionNext returns the card that should currently be shown in a session.
+// If a card is already being shown, the same card is returned again. Otherwise the next card from the queue is used,
+// and if the queue is empty, it's filled with new cards picked by the session's view. It returns nil if there are no
+// cards left.
+func (store *Store) SessionNext(id string) (*Session, *Card, error) {
+	store.sessions.mu.Lock()
+	defer store.sessions.mu.Unlock()
+
+	session, err := store.sessions.get(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if session.Finished() {
+		return nil, nil, fmt.Errorf("session %q has already ended", id)
+	}
+
+	profile := store.Profile(session.Profile)
+
+	if session.Current != "" {
+		card, err := profile.CardByID(session.Current)
+		if err == nil {
+			return session.clone(), card, nil
+		}
+
+		// The card has been removed since it was shown, so we fall through and pick a new one.
+		session.Current = ""
+	}
+
+	card := nextQueued(profile, session)
+	if card == nil {
+		err = store.fillQueue(session)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		card = nextQueued(profile, session)
+		if card == nil {
+			return session.clone(), nil, nil
+		}
+	}
+
+	session.Current = card.ID
+	session.CurrentServed = time.Now()
+
+	err = store.sessions.save(session)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return session.clone(), card, nil
+}
+
+// SessionAnswer records the answer for the card currently being shown in a session and adds it as a completion to
+// the card. The answer should be "perfect", "minor" or "major", or "skip" to move on without adding a completion.
+// If the duration is zero, the time since the card was first shown is used.
+func (store *Store) SessionAnswer(id string, answer string, duration time.Duration) (*Session, error) {
+	if answer != "perfect" && answer != "minor" && answer != "major" && answer != "skip" {
+		return nil, fmt.Errorf("invalid answer %q: please use 'perfect', 'minor', 'major' or 'skip'", answer)
+	}
+
+	store.sessions.mu.Lock()
+	defer store.sessions.mu.Unlock()
+
+	session, err := store.sessions.get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if session.Finished() {
+		return nil, fmt.Errorf("session %q has already ended", id)
+	}
+
+	if session.Current == "" {
+		return nil, fmt.Errorf("session %q isn't showing a card", id)
+	}
+
+	card, err := store.CardByID(session.Current)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if duration == 0 {
+		duration = now.Sub(session.CurrentServed)
+	}
+
+	if answer != "skip" {
+		err = store.Profile(session.Profile).AddCompletion(card.Path, answer, Completion{Date: now, Duration: duration})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	session.Results = append(session.Results, SessionResult{
+		CardID:   card.ID,
+		Path:     card.Path,
+		Answer:   answer,
+		Date:     now,
+		Duration: duration,
+	})
+
+	session.Elapsed += duration
+	session.Current = ""
+	session.CurrentServed = time.Time{}
+
+	err = store.sessions.save(session)
+	if err != nil {
+		return nil, err
+	}
+
+	return session.clone(), nil
+}
+
+// fillQueue asks the session's view for the next sessionQueueLength cards and adds them to the session's queue.
+func (store *Store) fillQueue(session *Session) error {
+	view := store.Views[session.ViewName]
+	if view == nil {
+		return fmt.Errorf("the view %q doesn't exist", session.ViewName)
+	}
+
+	set, _, err := store.Profile(session.Profile).SetFromConfig(session.Set)
+	if err != nil {
+		return err
+	}
+
+	for _, card := range pickCards(view, set, sessionQueueLength, store.sessionViewContext(session)) {
+		session.Queue = append(session.Queue, card.ID)
+	}
+
+	return nil
+}
+
+// nextQueued takes cards off the front of the session's queue until it finds one that still exists, returning nil
+// if the queue runs out.
+func nextQueued(profile *Profile, session *Session) *Card {
+	for len(session.Queue) > 0 {
+		card, err := profile.CardByID(session.Queue[0])
+		session.Queue = session.Queue[1:]
+
+		if err == nil {
+			return card
+		}
+	}
+
+	return nil
+}
+
+// sessionViewContext returns the context given to views when picking the next card in a session. Cards answered or
+// skipped recently in the session are avoided using the same limits as outside of sessions.
+func (store *Store) sessionViewContext(session *Session) ViewContext {
+	now := time.Now()
+
+	served := []servedCard{}
+	for _, result := range session.Results {
+		served = append(served, servedCard{id: result.CardID, at: result.Date})
+	}
+
+	return ViewContext{
+		Now:     now,
+		History: append([]SessionResult{}, session.Results...),
+		Recent:  recentIDs(served, now, store.recent.count, store.recent.duration),
+		Key:     "session/" + session.ID,
+	}
+}
+
+// EndSession finishes a session. Once a session has ended, no more cards can be answered as part of it.
+func (store *Store) EndSession(id string) (*Session, error) {
+	store.sessions.mu.Lock()
+	defer store.sessions.mu.Unlock()
+
+	session, err := store.sessions.get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if session.Finished() {
+		return session.clone(), nil
+	}
+
+	session.Ended = time.Now()
+	session.Current = ""
+	session.CurrentServed = time.Time{}
+	session.Queue = nil
+
+	err = store.sessions.save(session)
+	if err != nil {
+		return nil, err
+	}
+
+	return session.clone(), nil
+}
+
+// newSessionID returns a new ID for a session. IDs start with the time the session was created so that they sort
+// in a sensible order on disk, followed by random hex digits. These come from crypto/rand rather than math/rand,
+// which might never have been seeded when sergeant is used as a library, so that IDs don't repeat between runs and
+// overwrite an existing session or exam when it's saved.
+func newSessionID() string {
+	suffix := make([]byte, 8)
+
+	_, err := rand.Read(suffix)
+	if err != nil {
+		// This shouldn't happen, but the time is still better than nothing.
+		binary.BigEndian.PutUint64(suffix, uint64(time.Now().UnixNano()))
+	}
+
+	return fmt.Sprintf("%s-%s", time.Now().Format("20060102-150405"), hex.EncodeToString(suffix))
+}
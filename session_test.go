@@ -0,0 +1,63 @@
+package sergeant
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSessionStoreSaveLoad tests that sessions saved to disk can be loaded again by a new sessionStore.
+func TestSessionStoreSaveLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sergeant-sessions-")
+	if err != nil {
+		t.Fatalf("couldn't create temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	older := &Session{ID: "older", Started: time.Date(2021, 02, 16, 10, 18, 0, 0, time.UTC), Results: []SessionResult{}}
+	newer := &Session{
+		ID:       "newer",
+		SetName:  "all",
+		ViewName: "random",
+		Started:  time.Date(2021, 02, 17, 10, 18, 0, 0, time.UTC),
+		Ended:    time.Date(2021, 02, 17, 10, 48, 0, 0, time.UTC),
+		Results: []SessionResult{
+			{CardID: "BtIrmFTJo49QuJC4", Answer: "perfect", Duration: 5 * time.Minute},
+			{CardID: "0NiDQqGdzxTSipJa", Answer: "skip", Duration: time.Minute},
+		},
+		Elapsed: 6 * time.Minute,
+	}
+
+	sessions := newSessionStore(dir)
+	assert.NoError(t, sessions.save(older), "not expecting error saving session")
+	assert.NoError(t, sessions.save(newer), "not expecting error saving session")
+
+	loaded, err := newSessionStore(dir).list()
+	assert.NoError(t, err, "not expecting error listing sessions")
+	assert.Len(t, loaded, 2, "expected both sessions to be loaded")
+	assert.Equal(t, "newer", loaded[0].ID, "expected most recent session first")
+	assert.True(t, loaded[0].Finished(), "expected session to be finished")
+	assert.Equal(t, 1, loaded[0].Count("skip"), "expected skipped card to be loaded")
+	assert.Equal(t, 6*time.Minute, loaded[0].Elapsed, "expected elapsed time to be loaded")
+
+	_, err = newSessionStore(dir).get("../escape")
+	assert.Error(t, err, "expected error for session ID containing a path")
+}
+
+// TestNewSessionID tests that session IDs don't repeat, even when lots are made in the same second.
+func TestNewSessionID(t *testing.T) {
+	seen := map[string]bool{}
+
+	for i := 0; i < 1000; i++ {
+		id := newSessionID()
+		if !assert.False(t, seen[id], "expected session ID %q not to repeat", id) {
+			return
+		}
+
+		assert.Regexp(t, `^\d{8}-\d{6}-[0-9a-f]{16}$`, id, "expected session ID to start with the time")
+		seen[id] = true
+	}
+}
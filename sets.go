@@ -1,5 +1,10 @@
 package sergeant
 
+import (
+	"fmt"
+	"strings"
+)
+
 // Set represents a collection of cards.
 type Set struct {
 	Cards []*Card
@@ -22,3 +27,107 @@ func (s *Set) Filter(filters ...Filter) *Set {
 
 	return &Set{Cards: cardsNew}
 }
+
+// ConfigSet returns the config for the set with the given name, and whether it exists.
+func (store *Store) ConfigSet(name string) (ConfigSet, bool) {
+	store.setsMu.RLock()
+	defer store.setsMu.RUnlock()
+
+	set, ok := store.Sets[name]
+	return set, ok
+}
+
+// ConfigSets returns the config for every set, by name.
+func (store *Store) ConfigSets() map[string]ConfigSet {
+	store.setsMu.RLock()
+	defer store.setsMu.RUnlock()
+
+	sets := make(map[string]ConfigSet, len(store.Sets))
+	for name, set := range store.Sets {
+		sets[name] = set
+	}
+
+	return sets
+}
+
+// CreateSet adds a new set with the given name and writes it to the config file.
+// If the set doesn't have a display name, one is made from its name, so "revision-may-2020" becomes "Revision May 2020".
+func (store *Store) CreateSet(name string, set ConfigSet) (ConfigSet, error) {
+	return store.changeSet(name, &set, false)
+}
+
+// UpdateSet replaces the set with the given name and writes it to the config file.
+func (store *Store) UpdateSet(name string, set ConfigSet) (ConfigSet, error) {
+	return store.changeSet(name, &set, true)
+}
+
+// DeleteSet removes the set with the given name and removes it from the config file.
+func (store *Store) DeleteSet(name string) error {
+	_, err := store.changeSet(name, nil, true)
+	return err
+}
+
+// changeSet adds, replaces or (if set is nil) removes the set with the given name, both in the store and in the
+// config file. exists says whether the set should already exist.
+func (store *Store) changeSet(name string, set *ConfigSet, exists bool) (ConfigSet, error) {
+	if name == "" || strings.ContainsAny(name, " /") {
+		return ConfigSet{}, fmt.Errorf("invalid set name %q: set names can't be blank or contain spaces or slashes", name)
+	}
+
+	if name == "all" {
+		return ConfigSet{}, fmt.Errorf("the %q set is built in and can't be changed", name)
+	}
+
+	if set != nil {
+		if set.Name == "" {
+			set.Name = strings.Title(strings.ReplaceAll(name, "-", " "))
+		}
+
+		err := set.Validate()
+		if err != nil {
+			return ConfigSet{}, err
+		}
+	}
+
+	store.setsMu.Lock()
+	defer store.setsMu.Unlock()
+
+	_, ok := store.Sets[name]
+	if ok && !exists {
+		return ConfigSet{}, fmt.Errorf("set %q already exists", name)
+	} else if !ok && exists {
+		return ConfigSet{}, fmt.Errorf("set %q not found in config", name)
+	}
+
+	// The config file is written first, so that if it fails the sets in the store still match it.
+	if store.Config.Path != "" {
+		var rawConfigSet *rawConfigSetDef
+		if set != nil {
+			raw := configSetToRaw(*set)
+			rawConfigSet = &raw
+		}
+
+		err := writeConfigSet(store.Config.Path, name, rawConfigSet)
+		if err != nil {
+			return ConfigSet{}, err
+		}
+	}
+
+	// The map is replaced rather than modified so that anything still reading the old one isn't affected.
+	sets := make(map[string]ConfigSet, len(store.Sets))
+	for existingName, existingSet := range store.Sets {
+		sets[existingName] = existingSet
+	}
+
+	if set != nil {
+		sets[name] = *set
+	} else {
+		delete(sets, name)
+		set = &ConfigSet{}
+	}
+
+	store.Sets = sets
+	store.Config.Sets = sets
+
+	return *set, nil
+}
@@ -0,0 +1,78 @@
+package sergeant
+
+import (
+	"time"
+)
+
+// CardStats is a summary of how well a single card has been answered.
+type CardStats struct {
+	// Attempts is the total number of completions, and Perfect, Minor and Major are the number of each type.
+	Attempts int
+	Perfect  int
+	Minor    int
+	Major    int
+
+	// SuccessRate is the proportion of attempts, out of 1, that were perfect. It's zero if there are no attempts.
+	SuccessRate float64
+
+	MeanDuration   time.Duration
+	MedianDuration time.Duration
+
+	// Streak is the number of perfect completions in a row, counting back from the most recent.
+	Streak int
+
+	// LastSeen is when the card was last completed, or the zero time if it never has been.
+	LastSeen time.Time
+
+	// Difficulty is the predicted chance of answering the card perfectly, as worked out by the Difficulties view from
+	// how well the cards in the same category have been answered. Like in ProbabilityNode, lower means harder.
+	// DifficultyPath is the category the prediction comes from, or the empty string if the card isn't in one.
+	Difficulty     float64
+	DifficultyPath string
+}
+
+// Stats returns the statistics for a card. The set is used to predict the card's difficulty and should normally be
+// every card, so that the prediction takes into account all the cards in the same category.
+func (card *Card) Stats(set *Set) CardStats {
+	history := card.History()
+
+	stats := CardStats{
+		Attempts:       len(history),
+		Perfect:        len(card.CompletionsPerfect),
+		Minor:          len(card.CompletionsMinor),
+		Major:          len(card.CompletionsMajor),
+		MedianDuration: card.MedianDuration(),
+	}
+
+	if stats.Attempts > 0 {
+		var total time.Duration
+		for _, completion := range history {
+			total += completion.Duration
+		}
+
+		stats.SuccessRate = float64(stats.Perfect) / float64(stats.Attempts)
+		stats.MeanDuration = total / time.Duration(stats.Attempts)
+		stats.LastSeen = history[len(history)-1].Date
+	}
+
+	for i := len(history) - 1; i >= 0 && history[i].Type == "perfect"; i-- {
+		stats.Streak++
+	}
+
+	view := NewViewDifficulties(0)
+	stats.Difficulty = view.baseProbability
+
+	// The trie only contains categories, so the prediction comes from the most specific category the card is in.
+	pathTrie, _ := view.BuildTrie(set)
+	if node := categoryNode(pathTrie, card); node != nil {
+		stats.Difficulty = node.Difficulty
+		stats.DifficultyPath = node.Path
+	}
+
+	return stats
+}
+
+// CardStats returns the statistics for the card with the given ID, predicting its difficulty using every card.
+func (store *Store) CardStats(id string) (CardStats, error) {
+	return store.Profile(DefaultProfile).CardStats(id)
+}
@@ -0,0 +1,56 @@
+package sergeant
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCardStats tests the statistics calculated for a single card.
+func TestCardStats(t *testing.T) {
+	start := time.Date(2021, 02, 16, 10, 18, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	card := &Card{
+		ID:   "question",
+		Path: "maths/algebra/ex1/question",
+		CompletionsMajor: []Completion{
+			{Date: start, Duration: 10 * time.Minute},
+		},
+		CompletionsMinor: []Completion{
+			{Date: start.Add(day), Duration: 6 * time.Minute},
+		},
+		CompletionsPerfect: []Completion{
+			{Date: start.Add(2 * day), Duration: 4 * time.Minute},
+			{Date: start.Add(3 * day), Duration: 4 * time.Minute},
+		},
+	}
+
+	other := &Card{
+		ID:                 "other",
+		Path:               "maths/algebra/ex2/question",
+		CompletionsPerfect: []Completion{{Date: start, Duration: time.Minute}},
+	}
+
+	stats := card.Stats(&Set{Cards: []*Card{card, other}})
+
+	assert.Equal(t, 4, stats.Attempts)
+	assert.Equal(t, 2, stats.Perfect)
+	assert.Equal(t, 1, stats.Minor)
+	assert.Equal(t, 1, stats.Major)
+	assert.Equal(t, 0.5, stats.SuccessRate)
+	assert.Equal(t, 6*time.Minute, stats.MeanDuration)
+	assert.Equal(t, 5*time.Minute, stats.MedianDuration)
+	assert.Equal(t, 2, stats.Streak, "expected streak of the two most recent perfect completions")
+	assert.Equal(t, start.Add(3*day), stats.LastSeen)
+	assert.Equal(t, "maths/algebra", stats.DifficultyPath, "expected difficulty from most specific category")
+
+	// Both "maths" and "maths/algebra" have 3 perfect completions out of 5, which are combined with the difficulty of
+	// the level above starting from 0.5.
+	assert.InDelta(t, 0.56606, stats.Difficulty, 0.00001, "expected difficulty of maths/algebra")
+
+	unseen := (&Card{ID: "unseen", Path: "question"}).Stats(&Set{})
+
+	assert.Equal(t, CardStats{Difficulty: 0.5}, unseen, "expected empty stats for card that's never been completed")
+}
@@ -2,71 +2,551 @@ package sergeant
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/albatross-org/go-albatross/albatross"
 )
 
 // Store is an abstraction over an *albatross.Store that allows for updating cards.
+// Parsed cards are kept in an in-memory index so that the underlying store doesn't have to be read on every request.
+// Changes made through the Store are reflected in the index immediately. The underlying store is checked for changes
+// made outside of it every ReloadInterval, and they're picked up then or when Reload is called.
 type Store struct {
 	albatross *albatross.Store
 	Config    Config
-	Sets      map[string]ConfigSet
+
+	// Sets are the sets available, by name. They can be changed while the program is running using CreateSet,
+	// UpdateSet and DeleteSet, which replace the map rather than modifying it, so ConfigSet and ConfigSets should be
+	// used to read them safely.
+	Sets   map[string]ConfigSet
+	setsMu sync.RWMutex
+
+	// Views are the views available, by name. These are any defined in the config, or the DefaultViews if there isn't
+	// a views section, along with any registered using RegisterView.
+	Views map[string]View
+
+	// writeMu is held while a card's entry is being changed. Changes read the entry, modify it and write the whole
+	// thing back, so without it two changes at once, like completions from different profiles on a shared card, would
+	// overwrite each other.
+	writeMu sync.Mutex
+
+	// thumbnailsMu is held while old thumbnails are being removed from the cache.
+	thumbnailsMu sync.Mutex
+
+	index    *cardIndex
+	sessions *sessionStore
+	exams    *examStore
+	recent   *recentCards
 }
 
 // NewStore returns a new Store from an *albatross.Store and a config.
 func NewStore(store *albatross.Store, config Config) *Store {
+	views := map[string]View{}
+
+	// Views defined in the config replace the built-in ones, rather than being added to them.
+	if config.Views == nil {
+		for name, view := range DefaultViews {
+			views[name] = view
+		}
+	}
+
+	for _, name := range registeredViewNames() {
+		views[name], _ = registeredView(name)
+	}
+
+	for name, configView := range config.Views {
+		views[name] = configView.AsView()
+	}
+
+	recentCount := config.RecentCards
+	if recentCount == 0 {
+		recentCount = DefaultRecentCards
+	}
+
+	recentDuration := config.RecentDuration
+	if recentDuration == 0 {
+		recentDuration = DefaultRecentDuration
+	}
+
 	return &Store{
 		albatross: store,
 		Config:    config,
 		Sets:      config.Sets,
+		Views:     views,
+		index:     newCardIndex(),
+		sessions:  newSessionStore(config.SessionsPath),
+		exams:     newExamStore(config.ExamsPath),
+		recent:    newRecentCards(recentCount, recentDuration),
+	}
+}
+
+// Reload synchronises the card index with the underlying store. Only entries that have changed since the last
+// reload are parsed again.
+func (store *Store) Reload() error {
+	// The token is taken before the entries are read, so anything that changes in between is picked up next time.
+	token := store.changeToken()
+
+	collection, err := store.albatross.Collection()
+	if err != nil {
+		return fmt.Errorf("couldn't load cards from collection: %w", err)
+	}
+
+	store.index.sync(collection.List().Slice(), token)
+	return nil
+}
+
+// loadIndex reloads the card index if it has never been loaded, or if the reload interval has passed since the
+// underlying store was last checked and it's changed since.
+func (store *Store) loadIndex() error {
+	interval := store.Config.ReloadInterval
+	if interval == 0 {
+		interval = DefaultReloadInterval
+	}
+
+	if !store.index.due(interval) || !store.index.changed(store.changeToken()) {
+		return nil
+	}
+
+	return store.Reload()
+}
+
+// changeToken returns a token that changes whenever a file in the underlying store does. If it can't be worked out,
+// such as for an encrypted store, it returns zero and the index is reloaded every time it's checked instead.
+func (store *Store) changeToken() uint64 {
+	token, err := changeToken(store.albatross.Path)
+	if err != nil {
+		return 0
+	}
+
+	return token
+}
+
+// Cards returns every card in the store.
+// It returns the cards, followed by a map of warnings (paths -> parse errors) and an overall error if there was one.
+// The cards returned are shared between callers and shouldn't be modified.
+func (store *Store) Cards() ([]*Card, map[string]error, error) {
+	err := store.loadIndex()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cards, warnings := store.index.cards()
+	return cards, warnings, nil
+}
+
+// CardByID returns the card with the given ID.
+func (store *Store) CardByID(id string) (*Card, error) {
+	err := store.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	if card := store.index.getByID(id); card != nil {
+		return card, nil
+	}
+
+	// The card might have been added since the index was last loaded.
+	err = store.Reload()
+	if err != nil {
+		return nil, err
+	}
+
+	if card := store.index.getByID(id); card != nil {
+		return card, nil
+	}
+
+	return nil, fmt.Errorf("card with ID %q not found: %w", id, ErrNoCard)
+}
+
+// CardByPath returns the card at the given path.
+func (store *Store) CardByPath(path string) (*Card, error) {
+	err := store.loadIndex()
+	if err != nil {
+		return nil, err
 	}
+
+	if card := store.index.getByPath(path); card != nil {
+		return card, nil
+	}
+
+	// The card might have been added since the index was last loaded, so we fall back to asking the underlying store.
+	entry, err := store.albatross.Get(path)
+	if err != nil {
+		return nil, err
+	}
+
+	store.index.update(entry)
+	if card := store.index.getByPath(path); card != nil {
+		return card, nil
+	}
+
+	// If the entry isn't in the index after updating it, it must be malformed. Parsing it again gives us the reason.
+	return cardFromEntry(entry)
+}
+
+// refresh re-parses a single entry in the index after it has been written to.
+func (store *Store) refresh(path string) error {
+	entry, err := store.albatross.Get(path)
+	if err != nil {
+		return err
+	}
+
+	store.index.update(entry)
+	return nil
 }
 
 // Set returns the cards present in the set specified.
 // It knows what cards you want in the set from the .Sets configuration.
 // It returns a Set, followed by a map of warnings (paths -> parse errors) and an overall error if there was one.
 func (store *Store) Set(name string) (*Set, map[string]error, error) {
-	if store.Sets[name].Name == "" {
-		return nil, nil, fmt.Errorf("set %q not found in config", name)
-	}
-
-	config := store.Sets[name]
-	return store.SetFromConfig(config)
+	return store.Profile(DefaultProfile).Set(name)
 }
 
 // SetFromConfig returns the cards present in the set specified by the config.
 // It returns a Set, followed by a map of warnings (paths -> parse errors) and an overall error if there was one.
 func (store *Store) SetFromConfig(config ConfigSet) (*Set, map[string]error, error) {
-	collection, err := store.albatross.Collection()
+	return store.Profile(DefaultProfile).SetFromConfig(config)
+}
+
+// AddCompletion adds a completion to an entry in the store.
+func (store *Store) AddCompletion(path string, completionType string, completion Completion) error {
+	return store.Profile(DefaultProfile).AddCompletion(path, completionType, completion)
+}
+
+// RemoveCompletion removes the completion made at the given date (to the nearest minute) from the card with the
+// given ID. If date is the zero time, the card's most recent completion is removed. The removed completion is returned.
+func (store *Store) RemoveCompletion(id string, date time.Time) (TypedCompletion, error) {
+	return store.Profile(DefaultProfile).RemoveCompletion(id, date)
+}
+
+// AmendCompletion changes the completion made at the given date (to the nearest minute) on the card with the given ID
+// to the completion type and completion specified. If the new completion's date or duration are zero, the ones from the
+// original completion are kept. This is useful for correcting a completion that was marked with the wrong result. The
+// amended completion is returned.
+func (store *Store) AmendCompletion(id string, date time.Time, completionType string, completion Completion) (TypedCompletion, error) {
+	return store.Profile(DefaultProfile).AmendCompletion(id, date, completionType, completion)
+}
+
+// cleanCardPath cleans a path to a category given by a user, such as the new path of a card being moved. It returns an
+// error if the path is blank or would point outside of the store, like "../../notes" or "/etc".
+func cleanCardPath(path string) (string, error) {
+	if strings.TrimSpace(path) == "" {
+		return "", fmt.Errorf("the path is blank")
+	}
+
+	if filepath.IsAbs(path) || strings.HasPrefix(path, "/") {
+		return "", fmt.Errorf("the path %q must be relative to the store", path)
+	}
+
+	cleaned := filepath.ToSlash(filepath.Clean(path))
+
+	for _, part := range strings.Split(cleaned, "/") {
+		if part == ".." {
+			return "", fmt.Errorf("the path %q points outside of the store", path)
+		}
+	}
+
+	if cleaned == "." {
+		return "", fmt.Errorf("the path is blank")
+	}
+
+	return cleaned, nil
+}
+
+// MoveCard moves the card with the given ID into a different category, such as when it was filed under the wrong
+// chapter. The path given is the new parent of the card, like "further-maths/core-pure-1/chapter-2-series". The card
+// keeps its name, completions and attachments. It returns the card at its new path.
+func (store *Store) MoveCard(id string, path string) (*Card, error) {
+	store.writeMu.Lock()
+	defer store.writeMu.Unlock()
+
+	card, err := store.CardByID(id)
 	if err != nil {
-		return nil, nil, fmt.Errorf("couldn't create set from collection: %w", err)
+		return nil, err
 	}
 
-	filter := config.AsFilter()
-	slice := collection.List().Slice()
+	path, err = cleanCardPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("can't move card %q: %w", id, err)
+	}
+
+	newPath := filepath.Join(path, filepath.Base(card.Path))
+	if newPath == card.Path {
+		return card, nil
+	}
+
+	entry, err := store.albatross.Get(card.Path)
+	if err != nil {
+		return nil, err
+	}
 
-	cards := []*Card{}
-	warnings := map[string]error{}
+	content, err := card.Content()
+	if err != nil {
+		return nil, err
+	}
 
-	for _, entry := range slice {
-		card, err := cardFromEntry(entry)
+	// The entry is copied to its new path before the old one is deleted, so that if anything goes wrong part way
+	// through the card still exists somewhere.
+	err = store.albatross.Create(newPath, content)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create card entry at %q: %w", newPath, err)
+	}
+
+	// If the move fails after this, the copy is deleted so that there aren't two cards with the same ID.
+	for _, attachment := range entry.Attachments {
+		err = store.albatross.AttachCopyWithName(newPath, attachment.AbsPath, attachment.Name)
 		if err != nil {
-			warnings[entry.Path] = err
+			return nil, store.undoCreate(newPath, fmt.Errorf("couldn't copy attachment %q to %q: %w", attachment.Name, newPath, err))
+		}
+	}
+
+	err = store.albatross.Delete(card.Path)
+	if err != nil {
+		return nil, store.undoCreate(newPath, fmt.Errorf("couldn't delete card entry at %q: %w", card.Path, err))
+	}
+
+	store.index.delete(card.Path)
+
+	err = store.refresh(newPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return store.CardByPath(newPath)
+}
+
+// undoCreate deletes the entry at path after something went wrong creating it, returning the original error along with
+// any error from deleting it.
+func (store *Store) undoCreate(path string, err error) error {
+	deleteErr := store.albatross.Delete(path)
+	if deleteErr != nil {
+		return fmt.Errorf("%w (and couldn't delete the partial copy at %q: %s)", err, path, deleteErr)
+	}
+
+	return err
+}
+
+// SetTags replaces the tags of the card with the given ID.
+func (store *Store) SetTags(id string, tags []string) error {
+	card, err := store.CardByID(id)
+	if err != nil {
+		return err
+	}
+
+	return store.updateCard(card.Path, func(card *Card) error {
+		card.Tags = tags
+		return nil
+	})
+}
+
+// ReplaceQuestionImage replaces the question image of the card with the given ID with the image at imagePath.
+func (store *Store) ReplaceQuestionImage(id string, imagePath string) error {
+	return store.ReplaceQuestionImages(id, []string{imagePath})
+}
+
+// ReplaceAnswerImage replaces the answer image of the card with the given ID with the image at imagePath, such as when
+// the original was scanned with part of the answer cropped off.
+func (store *Store) ReplaceAnswerImage(id string, imagePath string) error {
+	return store.ReplaceAnswerImages(id, []string{imagePath})
+}
+
+// ReplaceQuestionImages replaces every page of the question of the card with the given ID with the images at
+// imagePaths, in order.
+func (store *Store) ReplaceQuestionImages(id string, imagePaths []string) error {
+	store.writeMu.Lock()
+	defer store.writeMu.Unlock()
+
+	return store.replaceImages(id, "question", imagePaths, func(kind string, alternative int) bool {
+		return kind == "question"
+	})
+}
+
+// ReplaceAnswerImages replaces every page of the answer of the card with the given ID with the images at imagePaths,
+// in order. Alternative solutions are left alone.
+func (store *Store) ReplaceAnswerImages(id string, imagePaths []string) error {
+	store.writeMu.Lock()
+	defer store.writeMu.Unlock()
+
+	return store.replaceImages(id, "answer", imagePaths, func(kind string, alternative int) bool {
+		return kind == "answer"
+	})
+}
+
+// AddAlternative adds another solution to the card with the given ID, with the images at imagePaths as its pages.
+func (store *Store) AddAlternative(id string, imagePaths []string) error {
+	store.writeMu.Lock()
+	defer store.writeMu.Unlock()
+
+	card, err := store.CardByID(id)
+	if err != nil {
+		return err
+	}
+
+	entry, err := store.albatross.Get(card.Path)
+	if err != nil {
+		return err
+	}
+
+	// Alternatives that were removed by hand can leave gaps, so the new one is numbered after the highest one.
+	n := 1
+	for _, attachment := range entry.Attachments {
+		if kind, alternative, _, ok := parseAttachmentName(attachment.Name); ok && kind == "alternative" && alternative >= n {
+			n = alternative + 1
+		}
+	}
+
+	return store.replaceImages(id, AlternativeKind(n), imagePaths, func(kind string, alternative int) bool {
+		return false
+	})
+}
+
+// RemoveAlternative removes the nth alternative solution of the card with the given ID, counting from 1 in the
+// order of the card's Alternatives.
+func (store *Store) RemoveAlternative(id string, n int) error {
+	store.writeMu.Lock()
+	defer store.writeMu.Unlock()
+
+	card, err := store.CardByID(id)
+	if err != nil {
+		return err
+	}
+
+	if n < 1 || n > len(card.Alternatives) {
+		return fmt.Errorf("card %q doesn't have an alternative solution %d", id, n)
+	}
+
+	for _, path := range card.Alternatives[n-1] {
+		err = os.Remove(path)
+		if err != nil {
+			return fmt.Errorf("couldn't remove alternative solution %d: %w", n, err)
+		}
+	}
+
+	return store.refresh(card.Path)
+}
+
+// replaceImages removes the attachments of a card that match, given the kind and alternative number from their name,
+// and attaches the images at imagePaths in their place as pages of kind, like "answer-1.png" and "answer-2.png".
+// The caller must hold writeMu.
+func (store *Store) replaceImages(id string, kind string, imagePaths []string, match func(kind string, alternative int) bool) error {
+	if len(imagePaths) == 0 {
+		return fmt.Errorf("no %s images given", kind)
+	}
+
+	for _, imagePath := range imagePaths {
+		if _, err := os.Stat(imagePath); err != nil {
+			return fmt.Errorf("couldn't read %s image %q: %w", kind, imagePath, err)
+		}
+
+		if !IsMediaFile(imagePath) {
+			return fmt.Errorf("can't use %q as a %s image: the file type isn't supported", imagePath, kind)
+		}
+	}
+
+	card, err := store.CardByID(id)
+	if err != nil {
+		return err
+	}
+
+	entry, err := store.albatross.Get(card.Path)
+	if err != nil {
+		return err
+	}
+
+	// Albatross doesn't have a way of removing attachments, so the old images are moved aside in the entry's directory
+	// directly. This has to happen first in case the new images have the same names. They're only deleted once the
+	// new images are attached, so that the card isn't left without a question or answer if attaching fails.
+	moved := map[string]string{}
+
+	for _, attachment := range entry.Attachments {
+		attachmentKind, alternative, _, ok := parseAttachmentName(attachment.Name)
+		if !ok || !match(attachmentKind, alternative) {
 			continue
 		}
 
-		if filter(card) {
-			cards = append(cards, card)
+		// The ".old" extension means it isn't picked up as an image of the card in the meantime.
+		aside := attachment.AbsPath + ".old"
+
+		err = os.Rename(attachment.AbsPath, aside)
+		if err != nil {
+			return store.restoreImages(card.Path, nil, moved, fmt.Errorf("couldn't move old %s image %q: %w", kind, attachment.Name, err))
+		}
+
+		moved[attachment.AbsPath] = aside
+	}
+
+	attached := []string{}
+
+	for i, imagePath := range imagePaths {
+		name := AttachmentName(kind, i+1, len(imagePaths), strings.ToLower(filepath.Ext(imagePath)))
+
+		err = store.albatross.AttachCopyWithName(card.Path, imagePath, name)
+		if err != nil {
+			return store.restoreImages(card.Path, attached, moved, fmt.Errorf("couldn't attach %s image %q: %w", kind, imagePath, err))
+		}
+
+		attached = append(attached, name)
+	}
+
+	for _, aside := range moved {
+		err = os.Remove(aside)
+		if err != nil {
+			return fmt.Errorf("couldn't remove old %s image %q: %w", kind, aside, err)
+		}
+	}
+
+	return store.refresh(card.Path)
+}
+
+// restoreImages undoes a failed replaceImages, removing the attachments of the entry at path that were attached and
+// moving the old images back. It returns the original error along with any error from restoring them.
+func (store *Store) restoreImages(path string, attached []string, moved map[string]string, err error) error {
+	errs := []string{}
+
+	entry, getErr := store.albatross.Get(path)
+	if getErr != nil {
+		errs = append(errs, getErr.Error())
+	} else {
+		for _, attachment := range entry.Attachments {
+			for _, name := range attached {
+				if attachment.Name != name {
+					continue
+				}
+
+				if removeErr := os.Remove(attachment.AbsPath); removeErr != nil {
+					errs = append(errs, removeErr.Error())
+				}
+			}
+		}
+	}
+
+	for original, aside := range moved {
+		if renameErr := os.Rename(aside, original); renameErr != nil {
+			errs = append(errs, renameErr.Error())
 		}
 	}
 
-	return &Set{
-		Cards: cards,
-	}, warnings, nil
+	refreshErr := store.refresh(path)
+	if refreshErr != nil {
+		errs = append(errs, refreshErr.Error())
+	}
+
+	if len(errs) != 0 {
+		return fmt.Errorf("%w (and couldn't restore the old images: %s)", err, strings.Join(errs, "; "))
+	}
+
+	return err
 }
 
-// AddCompletion adds a completion to an entry in the store.
-func (store *Store) AddCompletion(path string, completionType string, completion Completion) error {
+// updateCard reads the card at the given path from the underlying store, applies a change to it and writes it back.
+func (store *Store) updateCard(path string, change func(card *Card) error) error {
+	store.writeMu.Lock()
+	defer store.writeMu.Unlock()
+
 	entry, err := store.albatross.Get(path)
 	if err != nil {
 		return err
@@ -77,15 +557,9 @@ func (store *Store) AddCompletion(path string, completionType string, completion
 		return err
 	}
 
-	switch completionType {
-	case "perfect":
-		card.CompletionsPerfect = append(card.CompletionsPerfect, completion)
-	case "minor":
-		card.CompletionsMinor = append(card.CompletionsMinor, completion)
-	case "major":
-		card.CompletionsMajor = append(card.CompletionsMajor, completion)
-	default:
-		return fmt.Errorf("invalid completion type %q", completionType)
+	err = change(card)
+	if err != nil {
+		return err
 	}
 
 	newContent, err := card.Content()
@@ -98,5 +572,23 @@ func (store *Store) AddCompletion(path string, completionType string, completion
 		return err
 	}
 
-	return nil
+	return store.refresh(path)
+}
+
+// Next asks a view for the next card from a set outside of a session, avoiding cards recently served for the same
+// key. The key should identify where the requests are coming from, such as the name of the set being studied.
+func (store *Store) Next(key string, view View, set *Set) *Card {
+	now := time.Now()
+
+	card := NextCard(view, set, ViewContext{
+		Now:    now,
+		Recent: store.recent.ids(key, now),
+		Key:    key,
+	})
+
+	if card != nil {
+		store.recent.add(key, card.ID, now)
+	}
+
+	return card
 }
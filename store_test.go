@@ -0,0 +1,41 @@
+package sergeant
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCleanCardPath tests that paths given when moving a card are cleaned, and that ones pointing outside of the store
+// are rejected.
+func TestCleanCardPath(t *testing.T) {
+	valid := map[string]string{
+		"further-maths/core-pure-1":               "further-maths/core-pure-1",
+		"further-maths/core-pure-1/":              "further-maths/core-pure-1",
+		"further-maths//core-pure-1/./chapter-2":  "further-maths/core-pure-1/chapter-2",
+		"further-maths/core-pure-1/../statistics": "further-maths/statistics",
+	}
+
+	for path, expected := range valid {
+		cleaned, err := cleanCardPath(path)
+		if assert.NoError(t, err, "expected no error cleaning %q", path) {
+			assert.Equal(t, expected, cleaned, "expected %q to be cleaned correctly", path)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"/",
+		".",
+		"..",
+		"../../x",
+		"further-maths/../../x",
+		"/etc/passwd",
+		"further-maths/../..",
+	}
+
+	for _, path := range invalid {
+		_, err := cleanCardPath(path)
+		assert.Error(t, err, "expected an error cleaning %q", path)
+	}
+}
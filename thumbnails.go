@@ -0,0 +1,226 @@
+package sergeant
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	// GIF images are only ever decoded, so the decoder has to be registered with image.Decode explicitly.
+	_ "image/gif"
+)
+
+// MaxThumbnailWidth is the widest thumbnail that can be asked for.
+const MaxThumbnailWidth = 4096
+
+// thumbnailWidths are the widths that thumbnails are actually made at. Widths asked for are rounded up to one of these,
+// so that every screen size doesn't get a thumbnail of its own.
+var thumbnailWidths = []int{320, 640, 960, 1280, 1920, 2560, MaxThumbnailWidth}
+
+// thumbnailsMaxSize is how many bytes of thumbnails are kept in the cache. Once there are more, the ones used least
+// recently are removed.
+var thumbnailsMaxSize int64 = 256 << 20
+
+// Thumbnail returns the path to a copy of the image at the path given, scaled down to at least the width given while
+// keeping its aspect ratio. If the image is no wider than that already or is in a format that can't be scaled, its own
+// path is returned.
+// Thumbnails are cached in the ThumbnailsPath from the config. They're named after the image's path, size and
+// modification time, so replacing an image means a new thumbnail is made rather than the old one being served. A
+// thumbnail's own modification time is when it was last used, so the image's should be used for caching headers.
+func (store *Store) Thumbnail(imagePath string, width int) (string, error) {
+	if width <= 0 || width > MaxThumbnailWidth {
+		return "", fmt.Errorf("invalid thumbnail width %d: must be between 1 and %d", width, MaxThumbnailWidth)
+	}
+
+	for _, thumbnailWidth := range thumbnailWidths {
+		if thumbnailWidth >= width {
+			width = thumbnailWidth
+			break
+		}
+	}
+
+	info, err := os.Stat(imagePath)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%d\x00%d\x00%d", imagePath, info.Size(), info.ModTime().UnixNano(), width)))
+	name := hex.EncodeToString(hash[:])
+
+	// The format isn't known until the image is decoded, so either could have been cached.
+	for _, ext := range []string{".jpg", ".png"} {
+		cached := filepath.Join(store.Config.ThumbnailsPath, name+ext)
+		if _, err := os.Stat(cached); err == nil {
+			// The modification time is when it was last used, so that pruneThumbnails keeps the ones still in use.
+			now := time.Now()
+			_ = os.Chtimes(cached, now, now)
+
+			return cached, nil
+		}
+	}
+
+	file, err := os.Open(imagePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	// SVGs, PDFs and WebP images can't be decoded, but SVGs and PDFs don't need scaling down anyway.
+	img, format, err := image.Decode(file)
+	if errors.Is(err, image.ErrFormat) {
+		return imagePath, nil
+	} else if err != nil {
+		return "", fmt.Errorf("couldn't decode image %q: %w", imagePath, err)
+	}
+
+	if img.Bounds().Dx() <= width {
+		return imagePath, nil
+	}
+
+	thumbnail := downscale(img, width)
+
+	err = os.MkdirAll(store.Config.ThumbnailsPath, 0755)
+	if err != nil {
+		return "", fmt.Errorf("couldn't create thumbnails directory: %w", err)
+	}
+
+	// Thumbnails are written to a temporary file first so that a thumbnail being made by one request is never served
+	// half-written to another.
+	tmp, err := ioutil.TempFile(store.Config.ThumbnailsPath, name+"-*.tmp")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	ext := ".png"
+	if format == "jpeg" {
+		ext = ".jpg"
+		err = jpeg.Encode(tmp, thumbnail, &jpeg.Options{Quality: 85})
+	} else {
+		err = png.Encode(tmp, thumbnail)
+	}
+
+	closeErr := tmp.Close()
+	if err != nil {
+		return "", fmt.Errorf("couldn't encode thumbnail of %q: %w", imagePath, err)
+	}
+	if closeErr != nil {
+		return "", closeErr
+	}
+
+	cached := filepath.Join(store.Config.ThumbnailsPath, name+ext)
+
+	err = os.Rename(tmp.Name(), cached)
+	if err != nil {
+		return "", err
+	}
+
+	err = store.pruneThumbnails()
+	if err != nil {
+		return "", fmt.Errorf("couldn't prune thumbnails: %w", err)
+	}
+
+	return cached, nil
+}
+
+// pruneThumbnails removes the thumbnails that were used least recently until the cache is no bigger than
+// thumbnailsMaxSize.
+func (store *Store) pruneThumbnails() error {
+	store.thumbnailsMu.Lock()
+	defer store.thumbnailsMu.Unlock()
+
+	infos, err := ioutil.ReadDir(store.Config.ThumbnailsPath)
+	if err != nil {
+		return err
+	}
+
+	thumbnails := []os.FileInfo{}
+	var size int64
+
+	for _, info := range infos {
+		// Temporary files belong to thumbnails that are still being made.
+		if info.IsDir() || filepath.Ext(info.Name()) == ".tmp" {
+			continue
+		}
+
+		thumbnails = append(thumbnails, info)
+		size += info.Size()
+	}
+
+	sort.Slice(thumbnails, func(i, j int) bool {
+		return thumbnails[i].ModTime().Before(thumbnails[j].ModTime())
+	})
+
+	for _, info := range thumbnails {
+		if size <= thumbnailsMaxSize {
+			break
+		}
+
+		err = os.Remove(filepath.Join(store.Config.ThumbnailsPath, info.Name()))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		size -= info.Size()
+	}
+
+	return nil
+}
+
+// downscale shrinks an image to the width given, keeping its aspect ratio. Each pixel in the result is the average
+// of the pixels it covers in the original, which keeps thin lines like handwriting readable.
+func downscale(img image.Image, width int) *image.NRGBA {
+	bounds := img.Bounds()
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+
+	height := srcHeight * width / srcWidth
+	if height < 1 {
+		height = 1
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*srcHeight/height
+		y1 := bounds.Min.Y + (y+1)*srcHeight/height
+		if y1 == y0 {
+			y1++
+		}
+
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*srcWidth/width
+			x1 := bounds.Min.X + (x+1)*srcWidth/width
+			if x1 == x0 {
+				x1++
+			}
+
+			var r, g, b, a, n uint64
+
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+
+			dst.Set(x, y, color.RGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(b / n),
+				A: uint16(a / n),
+			})
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,120 @@
+package sergeant
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDownscale tests that images are shrunk to the right size by averaging the pixels they cover.
+func TestDownscale(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+	for y := 0; y < 2; y++ {
+		img.Set(0, y, color.NRGBA{0, 0, 0, 255})
+		img.Set(1, y, color.NRGBA{255, 255, 255, 255})
+		img.Set(2, y, color.NRGBA{255, 0, 0, 255})
+		img.Set(3, y, color.NRGBA{255, 0, 0, 255})
+	}
+
+	small := downscale(img, 2)
+
+	assert.Equal(t, image.Rect(0, 0, 2, 1), small.Bounds(), "expected aspect ratio to be kept")
+	assert.Equal(t, color.NRGBA{127, 127, 127, 255}, small.NRGBAAt(0, 0), "expected black and white to average to grey")
+	assert.Equal(t, color.NRGBA{255, 0, 0, 255}, small.NRGBAAt(1, 0))
+}
+
+// TestThumbnail tests that thumbnails are made and cached, and that images which are already small enough are used
+// as they are.
+func TestThumbnail(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sergeant-thumbnails-")
+	if err != nil {
+		t.Fatalf("couldn't create temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	imagePath := filepath.Join(dir, "question.png")
+
+	file, err := os.Create(imagePath)
+	if err != nil {
+		t.Fatalf("couldn't create image: %s", err)
+	}
+
+	err = png.Encode(file, image.NewNRGBA(image.Rect(0, 0, 1000, 500)))
+	file.Close()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	store := &Store{Config: Config{ThumbnailsPath: filepath.Join(dir, "thumbnails")}}
+
+	thumbnailPath, err := store.Thumbnail(imagePath, 300)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.NotEqual(t, imagePath, thumbnailPath)
+
+	thumbnailFile, err := os.Open(thumbnailPath)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer thumbnailFile.Close()
+
+	config, err := png.DecodeConfig(thumbnailFile)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 320, config.Width, "expected width to be rounded up")
+		assert.Equal(t, 160, config.Height)
+	}
+
+	cachedPath, err := store.Thumbnail(imagePath, 310)
+	assert.NoError(t, err)
+	assert.Equal(t, thumbnailPath, cachedPath, "expected the cached thumbnail to be used for widths that round the same")
+
+	originalPath, err := store.Thumbnail(imagePath, 1000)
+	assert.NoError(t, err)
+	assert.Equal(t, imagePath, originalPath, "expected images smaller than the width to be used as they are")
+
+	_, err = store.Thumbnail(imagePath, 0)
+	assert.Error(t, err, "expected error for invalid width")
+}
+
+// TestPruneThumbnails tests that the thumbnails used least recently are removed once the cache is too big.
+func TestPruneThumbnails(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sergeant-thumbnails-")
+	if err != nil {
+		t.Fatalf("couldn't create temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	defer func(size int64) { thumbnailsMaxSize = size }(thumbnailsMaxSize)
+	thumbnailsMaxSize = 20
+
+	now := time.Now()
+
+	for i, name := range []string{"old.png", "middle.png", "new.png"} {
+		path := filepath.Join(dir, name)
+
+		err = ioutil.WriteFile(path, make([]byte, 10), 0644)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		used := now.Add(time.Duration(i) * time.Minute)
+		assert.NoError(t, os.Chtimes(path, used, used))
+	}
+
+	store := &Store{Config: Config{ThumbnailsPath: dir}}
+	assert.NoError(t, store.pruneThumbnails())
+
+	for name, kept := range map[string]bool{"old.png": false, "middle.png": true, "new.png": true} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		assert.Equal(t, kept, err == nil, "expected %s to be kept: %t", name, kept)
+	}
+}
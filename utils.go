@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // magicTable is used to detect the filetype of images attached to a Card.
@@ -16,6 +18,7 @@ var magicTable = map[string]string{
 	"\x89PNG\r\n\x1a\n": "image/png",
 	"GIF87a":            "image/gif",
 	"GIF89a":            "image/gif",
+	"%PDF-":             "application/pdf",
 }
 
 // mimeFromIncipit returns the mime type of an image file from its first few bytes or the empty string if the
@@ -29,6 +32,21 @@ func mimeFromIncipit(incipit []byte) string {
 		}
 	}
 
+	// WebP images are RIFF files, which have the size of the file between the "RIFF" and the type of the file.
+	if len(incipitStr) >= 12 && incipitStr[:4] == "RIFF" && incipitStr[8:12] == "WEBP" {
+		return "image/webp"
+	}
+
+	// SVGs are text, so they can start with an XML declaration, comments or whitespace before the <svg> element.
+	start := incipitStr
+	if len(start) > 1024 {
+		start = start[:1024]
+	}
+
+	if strings.Contains(start, "<svg") {
+		return "image/svg+xml"
+	}
+
 	return ""
 }
 
@@ -47,7 +65,7 @@ func encodeAsDataURI(path string) (string, error) {
 	}
 
 	mime := mimeFromIncipit(content)
-	if mime != "image/png" && mime != "image/jpeg" && mime != "image/gif" {
+	if mime == "" {
 		return "", fmt.Errorf("unrecognised mime type %q", mime)
 	}
 
@@ -56,3 +74,95 @@ func encodeAsDataURI(path string) (string, error) {
 
 	return prefix + encoded, nil
 }
+
+// ParseDuration parses a duration the same way as the durations in the config, so days ("d") and weeks ("w") can be
+// used as well as anything time.ParseDuration understands.
+func ParseDuration(s string) (time.Duration, error) {
+	return parseDuration(s)
+}
+
+// parseDuration is like time.ParseDuration but also understands days ("d") and weeks ("w"), so durations like "30d"
+// or "2w3d12h" can be used.
+func parseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+
+	var total time.Duration
+	var standard strings.Builder
+
+	for rest := s; rest != ""; {
+		numberEnd := strings.IndexFunc(rest, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+		if numberEnd <= 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+
+		unitEnd := strings.IndexFunc(rest[numberEnd:], func(r rune) bool { return (r >= '0' && r <= '9') || r == '.' })
+		if unitEnd == -1 {
+			unitEnd = len(rest) - numberEnd
+		}
+
+		number, unit := rest[:numberEnd], rest[numberEnd:numberEnd+unitEnd]
+		rest = rest[numberEnd+unitEnd:]
+
+		if unit != "d" && unit != "w" {
+			standard.WriteString(number + unit)
+			continue
+		}
+
+		value, err := strconv.ParseFloat(number, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q: %w", s, err)
+		}
+
+		if unit == "w" {
+			value *= 7
+		}
+
+		total += time.Duration(value * float64(24*time.Hour))
+	}
+
+	if standard.Len() > 0 {
+		duration, err := time.ParseDuration(standard.String())
+		if err != nil {
+			return 0, err
+		}
+
+		total += duration
+	}
+
+	return total, nil
+}
+
+// formatDuration formats a duration so that it can be read by parseDuration, using weeks and days where it can so that
+// long durations like 168 hours are written as "1w" rather than "168h0m0s".
+func formatDuration(duration time.Duration) string {
+	day := 24 * time.Hour
+	var formatted strings.Builder
+
+	if weeks := duration / (7 * day); weeks > 0 {
+		fmt.Fprintf(&formatted, "%dw", weeks)
+		duration -= weeks * 7 * day
+	}
+
+	if days := duration / day; days > 0 {
+		fmt.Fprintf(&formatted, "%dd", days)
+		duration -= days * day
+	}
+
+	if duration != 0 || formatted.Len() == 0 {
+		// Zero minutes and seconds are left off, so 12 hours is "12h" rather than "12h0m0s".
+		standard := duration.String()
+		if strings.HasSuffix(standard, "m0s") {
+			standard = strings.TrimSuffix(standard, "0s")
+		}
+
+		if strings.HasSuffix(standard, "h0m") {
+			standard = strings.TrimSuffix(standard, "0m")
+		}
+
+		formatted.WriteString(standard)
+	}
+
+	return formatted.String()
+}
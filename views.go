@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/dghubble/trie"
@@ -16,12 +17,14 @@ import (
 	wr "github.com/mroth/weightedrand"
 )
 
-// DefaultViews is a map containing the default Views used by the program.
+// DefaultViews is a map containing the default Views used by the program. They're safe for concurrent use.
 var DefaultViews = map[string]View{
 	"random":       NewViewRandom(time.Now().Unix()),
 	"unseen":       NewViewUnseen(time.Now().Unix()),
 	"difficulties": NewViewDifficulties(time.Now().Unix()),
 	"bayesian":     NewViewBayesian(time.Now().Unix()),
+	"spaced":       NewViewSpaced(),
+	"interleaved":  NewViewInterleaved(time.Now().Unix(), nil),
 }
 
 // View is a certain way of scheduling cards.
@@ -29,6 +32,169 @@ type View interface {
 	Next(set *Set) *Card
 }
 
+// ContextView is a View that can also make use of what's happened so far when picking the next card, such as the
+// cards already answered in the current session. Views don't have to implement this, but if they do it's used instead
+// of Next whenever the context is known.
+type ContextView interface {
+	View
+	NextWithContext(set *Set, ctx ViewContext) *Card
+}
+
+// ViewContext is the information given to a ContextView when it's asked for the next card.
+type ViewContext struct {
+	// Now is the current time. Views should use this rather than time.Now so that they can be tested.
+	Now time.Time
+
+	// History is the results of the current session so far, oldest first. It's empty if the card isn't being picked
+	// as part of a session.
+	History []SessionResult
+
+	// Recent holds the IDs of the cards served recently, most recent first.
+	Recent []string
+
+	// Key identifies where the requests for cards are coming from, such as a session or a set being studied outside of
+	// one, so that views can remember what they've done for each. It's empty if it isn't known.
+	Key string
+}
+
+// NextCard asks a view for the next card from a set, passing it the context if it's a ContextView.
+func NextCard(view View, set *Set, ctx ViewContext) *Card {
+	if contextView, ok := view.(ContextView); ok {
+		return contextView.NextWithContext(set, ctx)
+	}
+
+	return view.Next(set)
+}
+
+// SeededView is a View whose choices are random, but can be made repeatable by giving it a seed. Two views created
+// with WithSeed using the same seed will pick the same cards when given the same sets and contexts, which allows the
+// same random drill to be shared.
+type SeededView interface {
+	View
+	WithSeed(seed int64) View
+}
+
+// SeedView returns a copy of the view that makes its random choices using the seed given. Views which aren't a
+// SeededView, like Spaced, don't make random choices and are returned unchanged.
+func SeedView(view View, seed int64) View {
+	if seededView, ok := view.(SeededView); ok {
+		return seededView.WithSeed(seed)
+	}
+
+	return view
+}
+
+// lockedSource is a rand.Source64 that is safe for concurrent use, so that views can be shared between goroutines such
+// as the server's request handlers.
+type lockedSource struct {
+	mu  sync.Mutex
+	src rand.Source64
+}
+
+// Int63 returns a non-negative pseudo-random 63-bit integer.
+func (source *lockedSource) Int63() int64 {
+	source.mu.Lock()
+	defer source.mu.Unlock()
+
+	return source.src.Int63()
+}
+
+// Uint64 returns a pseudo-random 64-bit integer.
+func (source *lockedSource) Uint64() uint64 {
+	source.mu.Lock()
+	defer source.mu.Unlock()
+
+	return source.src.Uint64()
+}
+
+// Seed sets the seed of the underlying source.
+func (source *lockedSource) Seed(seed int64) {
+	source.mu.Lock()
+	defer source.mu.Unlock()
+
+	source.src.Seed(seed)
+}
+
+// newRand returns a new *rand.Rand with the given seed that is safe for concurrent use.
+func newRand(seed int64) *rand.Rand {
+	return rand.New(&lockedSource{src: rand.NewSource(seed).(rand.Source64)})
+}
+
+// ViewFactory creates a new instance of a view.
+type ViewFactory func() View
+
+var (
+	viewFactoriesMu sync.RWMutex
+	viewFactories   = map[string]ViewFactory{}
+)
+
+// RegisterView makes a view available under the name given, so that programs using this package can add their own
+// ways of scheduling cards. Every Store created afterwards gets its own instance of the view from the factory, and the
+// name can also be used as the type of a view in the config, although it can't be given any parameters there.
+// Views should be registered before the config is loaded, such as in an init function.
+// It panics if the name is already taken by a built-in or registered view, or if the factory is nil.
+func RegisterView(name string, factory ViewFactory) {
+	viewFactoriesMu.Lock()
+	defer viewFactoriesMu.Unlock()
+
+	if factory == nil {
+		panic("sergeant: RegisterView factory is nil")
+	}
+
+	if _, exists := DefaultViews[name]; exists {
+		panic("sergeant: RegisterView called with the name of a built-in view " + name)
+	}
+
+	if _, exists := viewFactories[name]; exists {
+		panic("sergeant: RegisterView called twice for view " + name)
+	}
+
+	viewFactories[name] = factory
+}
+
+// unregisterView removes a view registered with RegisterView, so that tests can register the same view again.
+func unregisterView(name string) {
+	viewFactoriesMu.Lock()
+	defer viewFactoriesMu.Unlock()
+
+	delete(viewFactories, name)
+}
+
+// registeredView returns a new instance of the registered view with the given name, or false if there isn't one.
+func registeredView(name string) (View, bool) {
+	viewFactoriesMu.RLock()
+	defer viewFactoriesMu.RUnlock()
+
+	factory, exists := viewFactories[name]
+	if !exists {
+		return nil, false
+	}
+
+	return factory(), true
+}
+
+// viewRegistered reports whether a view has been registered with the given name.
+func viewRegistered(name string) bool {
+	viewFactoriesMu.RLock()
+	defer viewFactoriesMu.RUnlock()
+
+	_, exists := viewFactories[name]
+	return exists
+}
+
+// registeredViewNames returns the names of all registered views.
+func registeredViewNames() []string {
+	viewFactoriesMu.RLock()
+	defer viewFactoriesMu.RUnlock()
+
+	names := []string{}
+	for name := range viewFactories {
+		names = append(names, name)
+	}
+
+	return names
+}
+
 // Random selects a random card from all possible cards.
 type Random struct {
 	rng *rand.Rand
@@ -37,15 +203,38 @@ type Random struct {
 // NewViewRandom returns a new Random view with the given seed.
 func NewViewRandom(seed int64) *Random {
 	return &Random{
-		rng: rand.New(rand.NewSource(seed)),
+		rng: newRand(seed),
 	}
 }
 
+// WithSeed returns a new Random view with the given seed.
+func (view *Random) WithSeed(seed int64) View {
+	return NewViewRandom(seed)
+}
+
 // Next looks at all previous cards and decides what card to show next.
 func (view *Random) Next(set *Set) *Card {
 	return set.Cards[view.rng.Intn(len(set.Cards))]
 }
 
+// NextWithContext is like Next, but avoids cards that have been served recently unless there aren't any others.
+func (view *Random) NextWithContext(set *Set, ctx ViewContext) *Card {
+	recent := recentSet(ctx)
+
+	candidates := []*Card{}
+	for _, card := range set.Cards {
+		if !recent[card.ID] {
+			candidates = append(candidates, card)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return view.Next(set)
+	}
+
+	return candidates[view.rng.Intn(len(candidates))]
+}
+
 // Unseen selects cards that have yet to come up.
 type Unseen struct {
 	rng *rand.Rand
@@ -54,15 +243,38 @@ type Unseen struct {
 // NewViewUnseen returns a new Unseen view with the given seed.
 func NewViewUnseen(seed int64) *Unseen {
 	return &Unseen{
-		rng: rand.New(rand.NewSource(seed)),
+		rng: newRand(seed),
 	}
 }
 
+// WithSeed returns a new Unseen view with the given seed.
+func (view *Unseen) WithSeed(seed int64) View {
+	return NewViewUnseen(seed)
+}
+
 // Next looks at all previous cards and decides what card to show next.
 func (view *Unseen) Next(set *Set) *Card {
+	return view.next(set, nil)
+}
+
+// NextWithContext is like Next, but avoids cards that have been served recently unless there aren't any others.
+func (view *Unseen) NextWithContext(set *Set, ctx ViewContext) *Card {
+	if card := view.next(set, recentSet(ctx)); card != nil {
+		return card
+	}
+
+	return view.next(set, nil)
+}
+
+// next picks a card that has yet to come up, never picking any of the cards in exclude.
+func (view *Unseen) next(set *Set, exclude map[string]bool) *Card {
 	candidates := []*Card{}
 
 	for _, card := range set.Cards {
+		if exclude[card.ID] {
+			continue
+		}
+
 		if len(card.CompletionsMajor)+len(card.CompletionsMinor)+len(card.CompletionsPerfect) == 0 {
 			candidates = append(candidates, card)
 		}
@@ -92,7 +304,7 @@ type Difficulties struct {
 	// baseProbability is the probability assumed probability "one level higher" than the root of the tree.
 	baseProbability float64
 
-	// assumedSampleProbability is the probability used when no sample has been given.
+	// assumedSampleProbability is what the parent's probability is multiplied by when no sample has been given.
 	// By default, this is 0.6. This means that categories which haven't been questioned about will be explored.
 	assumedSampleProbability float64
 
@@ -106,14 +318,22 @@ type Difficulties struct {
 // NewViewDifficulties returns a new Difficulties view with the given seed.
 func NewViewDifficulties(seed int64) *Difficulties {
 	return &Difficulties{
-		rng:                      rand.New(rand.NewSource(seed)),
+		rng:                      newRand(seed),
 		baseProbability:          0.5,
-		assumedSampleProbability: 0.5,
+		assumedSampleProbability: 0.6,
 		topPercent:               0.4,
 		power:                    2,
 	}
 }
 
+// WithSeed returns a copy of the view with the same parameters but the given seed.
+func (view *Difficulties) WithSeed(seed int64) View {
+	seeded := *view
+	seeded.rng = newRand(seed)
+
+	return &seeded
+}
+
 // ProbabilityNode represents a node in the probability tree.
 type ProbabilityNode struct {
 	Path       string
@@ -121,6 +341,10 @@ type ProbabilityNode struct {
 	Minor      int
 	Major      int
 	Difficulty float64
+
+	// Cards is the number of cards under the node, and Unseen is how many of those have never been completed.
+	Cards  int
+	Unseen int
 }
 
 // BuildTrie creates a path trie that contains difficulty information.
@@ -161,7 +385,7 @@ func (view *Difficulties) BuildTrie(set *Set) (pathTrie *trie.PathTrie, paths []
 		if total == 0 {
 			// If we have no sample, we use a reduced version of the the parents probability. This means
 			// that the program will sometimes pick categories that haven't been looked at yet.
-			node.Difficulty = generalProbability * 0.6
+			node.Difficulty = generalProbability * view.assumedSampleProbability
 		} else {
 			// If we have a sample, we compute an adjusted difficulty probability that takes into account
 			// the overall probability of the underlying category.
@@ -176,7 +400,10 @@ func (view *Difficulties) BuildTrie(set *Set) (pathTrie *trie.PathTrie, paths []
 
 	// Sort the available paths by their difficulty in the probability trie.
 	// This means that the most difficult cards (those with the lowest probability) will come first.
-	sort.Slice(paths, func(i, j int) bool {
+	// The trie is walked in no particular order, so paths are sorted alphabetically first to make sure paths with the
+	// same difficulty always come out in the same order.
+	sort.Strings(paths)
+	sort.SliceStable(paths, func(i, j int) bool {
 		p1 := math.Pow(pathTrie.Get(paths[i]).(*ProbabilityNode).Difficulty, view.power)
 		p2 := math.Pow(pathTrie.Get(paths[j]).(*ProbabilityNode).Difficulty, view.power)
 
@@ -186,8 +413,60 @@ func (view *Difficulties) BuildTrie(set *Set) (pathTrie *trie.PathTrie, paths []
 	return pathTrie, paths
 }
 
+// TreeNode is a node in the nested version of the probability tree returned by Tree.
+type TreeNode struct {
+	ProbabilityNode
+
+	// Name is the last component of the node's path, such as "chapter-1-complex-numbers".
+	Name     string
+	Children []*TreeNode
+}
+
+// Tree returns the probability tree built by BuildTrie nested by path component, so that it can be drilled down
+// through one category at a time. It returns the top-level categories, and children are sorted by name.
+func (view *Difficulties) Tree(set *Set) []*TreeNode {
+	pathTrie, paths := view.BuildTrie(set)
+	sort.Strings(paths)
+
+	roots := []*TreeNode{}
+	nodes := map[string]*TreeNode{}
+
+	// Since the paths are sorted, a parent always comes before its children.
+	for _, path := range paths {
+		node := &TreeNode{
+			ProbabilityNode: *pathTrie.Get(path).(*ProbabilityNode),
+			Name:            filepath.Base(path),
+			Children:        []*TreeNode{},
+		}
+		nodes[path] = node
+
+		if parent, ok := nodes[filepath.Dir(path)]; ok {
+			parent.Children = append(parent.Children, node)
+		} else {
+			roots = append(roots, node)
+		}
+	}
+
+	return roots
+}
+
 // Next looks at all previous cards and decides what card to show next.
 func (view *Difficulties) Next(set *Set) *Card {
+	return view.next(set, nil)
+}
+
+// NextWithContext is like Next, but avoids cards that have been served recently unless there aren't any others.
+func (view *Difficulties) NextWithContext(set *Set, ctx ViewContext) *Card {
+	if card := view.next(set, recentSet(ctx)); card != nil {
+		return card
+	}
+
+	return view.next(set, nil)
+}
+
+// next picks the next card, never picking any of the cards in exclude.
+// Cards that are excluded still count towards the difficulty of their categories.
+func (view *Difficulties) next(set *Set, exclude map[string]bool) *Card {
 	pathTrie, paths := view.BuildTrie(set)
 
 	if len(paths) == 0 {
@@ -200,7 +479,7 @@ func (view *Difficulties) Next(set *Set) *Card {
 	// TODO: this is an expensive operation.
 	for _, path := range paths {
 		for _, card := range set.Cards {
-			if strings.HasPrefix(card.Path, path) && card.TotalCompletions() == 0 {
+			if strings.HasPrefix(card.Path, path) && card.TotalCompletions() == 0 && !exclude[card.ID] {
 				pathMap[path] = append(pathMap[path], card)
 			}
 		}
@@ -208,8 +487,13 @@ func (view *Difficulties) Next(set *Set) *Card {
 
 	pathsSubset := paths[:int(math.Ceil(float64(len(paths))*view.topPercent))]
 
+	// Only paths that have cards left to pick from are considered, otherwise a path might be chosen that has nothing in it.
 	choices := []wr.Choice{}
 	for _, path := range pathsSubset {
+		if len(pathMap[path]) == 0 {
+			continue
+		}
+
 		weightInt := uint(pathTrie.Get(path).(*ProbabilityNode).Difficulty * (10000000)) // Have to convert difficulty to uint.
 		choices = append(
 			choices,
@@ -217,26 +501,18 @@ func (view *Difficulties) Next(set *Set) *Card {
 		)
 	}
 
+	if len(choices) == 0 {
+		return nil
+	}
+
 	chooser, err := wr.NewChooser(choices...)
 	if err != nil {
 		logrus.Error("Error choosing question: ", err)
 		return nil
 	}
 
-	length := 0
-	for length == 0 {
-		path := chooser.Pick().(string)
-		questions := pathMap[path]
-		length := len(questions)
-
-		if length == 0 {
-			continue
-		}
-
-		return questions[view.rng.Intn(length)]
-	}
-
-	return nil
+	questions := pathMap[chooser.PickSource(view.rng).(string)]
+	return questions[view.rng.Intn(len(questions))]
 }
 
 // putOrUpdateTrie will put a trie value or update it if it already exists for this path.
@@ -246,14 +522,23 @@ func putOrUpdateTrie(trie *trie.PathTrie, path string, card *Card) {
 		existing.Perfect += len(card.CompletionsPerfect)
 		existing.Minor += len(card.CompletionsMinor)
 		existing.Major += len(card.CompletionsMajor)
+		existing.Cards++
+		if card.TotalCompletions() == 0 {
+			existing.Unseen++
+		}
 		trie.Put(path, existing)
 	} else {
-		trie.Put(path, &ProbabilityNode{
+		node := &ProbabilityNode{
 			Path:    path,
 			Perfect: len(card.CompletionsPerfect),
 			Minor:   len(card.CompletionsMinor),
 			Major:   len(card.CompletionsMajor),
-		})
+			Cards:   1,
+		}
+		if card.TotalCompletions() == 0 {
+			node.Unseen = 1
+		}
+		trie.Put(path, node)
 	}
 }
 
@@ -266,6 +551,222 @@ func adjustDifficultyProbability(generalProbability float64, sampleProbability f
 	return sampleProbability*sampleStrength + generalProbability*(1-sampleStrength)
 }
 
+// Interleaved rotates between the top-level topics in a set, like "maths", "physics" and "further-maths", so that
+// consecutive cards come from different subjects. Topics are visited in alphabetical order, and the ratios say how many
+// cards in a row should come from each topic before moving on to the next. Topics without a ratio get one card at a
+// time.
+// Within a topic, any card can be picked, but harder cards are more likely to be. How hard a card is comes from its
+// own completions combined with the difficulty of its category, worked out in the same way as the Difficulties view.
+// The position in the rotation is remembered separately for each key given in the context, so without a key it always
+// starts from the first topic.
+type Interleaved struct {
+	difficulties *Difficulties
+	ratios       map[string]int
+
+	// rotations is shared between copies of the view made by WithSeed, since seeded copies are made for each request.
+	rotations *interleavedRotations
+}
+
+// interleavedRotations remembers where in the rotation an Interleaved view is for each key.
+type interleavedRotations struct {
+	mu        sync.Mutex
+	positions map[string]*interleavedPosition
+
+	// pruned is when keys that haven't been used for a while were last removed.
+	pruned time.Time
+}
+
+// interleavedPosition is the topic an Interleaved view last served a card from, and how many it's served in a row.
+type interleavedPosition struct {
+	topic  string
+	served int
+	used   time.Time
+}
+
+// interleavedForgetAfter is how long the position in the rotation is kept for a key that isn't being used.
+const interleavedForgetAfter = 24 * time.Hour
+
+// NewViewInterleaved returns a new Interleaved view with the given seed and ratios, which map the names of top-level
+// topics to the number of cards in a row to serve from them.
+func NewViewInterleaved(seed int64, ratios map[string]int) *Interleaved {
+	if ratios == nil {
+		ratios = map[string]int{}
+	}
+
+	return &Interleaved{
+		difficulties: NewViewDifficulties(seed),
+		ratios:       ratios,
+		rotations:    &interleavedRotations{positions: map[string]*interleavedPosition{}},
+	}
+}
+
+// WithSeed returns a copy of the view with the same parameters and ratios but the given seed.
+func (view *Interleaved) WithSeed(seed int64) View {
+	return &Interleaved{
+		difficulties: view.difficulties.WithSeed(seed).(*Difficulties),
+		ratios:       view.ratios,
+		rotations:    view.rotations,
+	}
+}
+
+// Next looks at all previous cards and decides what card to show next.
+func (view *Interleaved) Next(set *Set) *Card {
+	return view.NextWithContext(set, ViewContext{Now: time.Now()})
+}
+
+// NextWithContext picks a card from the topic that comes next in the rotation for the key in the context. Cards that
+// have been served recently are avoided, so if every card in the topic has been, the one after is tried instead. If
+// every card in the set has been served recently, they're picked from anyway.
+func (view *Interleaved) NextWithContext(set *Set, ctx ViewContext) *Card {
+	byTopic := map[string][]*Card{}
+	for _, card := range set.Cards {
+		topic := cardTopic(card)
+		byTopic[topic] = append(byTopic[topic], card)
+	}
+
+	topics := []string{}
+	for topic := range byTopic {
+		topics = append(topics, topic)
+	}
+
+	if len(topics) == 0 {
+		return nil
+	}
+
+	sort.Strings(topics)
+
+	view.rotations.mu.Lock()
+	defer view.rotations.mu.Unlock()
+
+	position := view.rotations.get(ctx.Key, ctx.Now)
+
+	start := sort.SearchStrings(topics, position.topic)
+	if start < len(topics) && topics[start] == position.topic && position.served >= view.ratio(position.topic) {
+		start++
+	}
+
+	pathTrie, _ := view.difficulties.BuildTrie(set)
+
+	for _, exclude := range []map[string]bool{recentSet(ctx), nil} {
+		for i := range topics {
+			topic := topics[(start+i)%len(topics)]
+
+			card := view.pick(pathTrie, byTopic[topic], exclude)
+			if card == nil {
+				continue
+			}
+
+			if topic == position.topic {
+				position.served++
+			} else {
+				position.topic = topic
+				position.served = 1
+			}
+
+			return card
+		}
+	}
+
+	return nil
+}
+
+// pick chooses one of the cards given that isn't in exclude, weighted so that harder cards are more likely to be
+// picked.
+func (view *Interleaved) pick(pathTrie *trie.PathTrie, cards []*Card, exclude map[string]bool) *Card {
+	candidates := []*Card{}
+	choices := []wr.Choice{}
+
+	for _, card := range cards {
+		if exclude[card.ID] {
+			continue
+		}
+
+		difficulty := view.difficulties.baseProbability
+		if node := categoryNode(pathTrie, card); node != nil {
+			difficulty = node.Difficulty
+		}
+
+		if total := card.TotalCompletions(); total > 0 {
+			difficulty = adjustDifficultyProbability(difficulty, float64(len(card.CompletionsPerfect))/float64(total), total)
+		}
+
+		// The difficulty is the probability of getting the card right, so it's flipped to make harder cards more
+		// likely. One is added so that cards which are always got right can still come up.
+		weight := uint(math.Pow(1-difficulty, view.difficulties.power)*10000000) + 1
+
+		candidates = append(candidates, card)
+		choices = append(choices, wr.Choice{Item: len(candidates) - 1, Weight: weight})
+	}
+
+	if len(choices) == 0 {
+		return nil
+	}
+
+	chooser, err := wr.NewChooser(choices...)
+	if err != nil {
+		logrus.Error("Error choosing question: ", err)
+		return nil
+	}
+
+	return candidates[chooser.PickSource(view.difficulties.rng).(int)]
+}
+
+// ratio returns the number of cards in a row to serve from a topic.
+func (view *Interleaved) ratio(topic string) int {
+	if ratio, ok := view.ratios[topic]; ok && ratio > 0 {
+		return ratio
+	}
+
+	return 1
+}
+
+// get returns the position in the rotation for a key. Positions for an empty key aren't remembered. The caller must
+// hold mu.
+func (rotations *interleavedRotations) get(key string, now time.Time) *interleavedPosition {
+	if key == "" {
+		return &interleavedPosition{}
+	}
+
+	// Keys can come from clients, like seeds, so ones that haven't been used for a while are forgotten rather than being
+	// kept forever. This is only checked every so often since it has to look at every key.
+	if now.Sub(rotations.pruned) >= interleavedForgetAfter {
+		for key, position := range rotations.positions {
+			if now.Sub(position.used) >= interleavedForgetAfter {
+				delete(rotations.positions, key)
+			}
+		}
+
+		rotations.pruned = now
+	}
+
+	position, ok := rotations.positions[key]
+	if !ok {
+		position = &interleavedPosition{}
+		rotations.positions[key] = position
+	}
+
+	position.used = now
+
+	return position
+}
+
+// categoryNode returns the node for the most specific category a card is in from a trie built by BuildTrie, or nil if
+// the card isn't in any category.
+func categoryNode(pathTrie *trie.PathTrie, card *Card) *ProbabilityNode {
+	for path := card.PathParent(); path != "." && path != "/"; path = filepath.Dir(path) {
+		if node, ok := pathTrie.Get(path).(*ProbabilityNode); ok {
+			return node
+		}
+	}
+
+	return nil
+}
+
+// cardTopic returns the first component of a card's path, like "maths" for "maths/algebra/question-abcdef".
+func cardTopic(card *Card) string {
+	return strings.SplitN(card.Path, "/", 2)[0]
+}
+
 // Bayesian uses Bayesian inference in order to try and select the card you're most likely to get wrong.
 // The method comes from Probabilistic Programming and Bayesian Methods for Hackers:
 //   https://nbviewer.jupyter.org/github/CamDavidsonPilon/Probabilistic-Programming-and-Bayesian-Methods-for-Hackers/blob/master/Chapter6_Priorities/Ch6_Priors_PyMC2.ipynb
@@ -285,6 +786,21 @@ type bayesianBetaDistribution struct {
 
 // Next looks at all previous cards and decides what card to show next.
 func (view *Bayesian) Next(set *Set) *Card {
+	return view.next(set, nil)
+}
+
+// NextWithContext is like Next, but avoids cards that have been served recently unless there aren't any others.
+func (view *Bayesian) NextWithContext(set *Set, ctx ViewContext) *Card {
+	if card := view.next(set, recentSet(ctx)); card != nil {
+		return card
+	}
+
+	return view.next(set, nil)
+}
+
+// next picks the next card, never picking any of the cards in exclude.
+// Cards that are excluded still count towards the distributions of their categories.
+func (view *Bayesian) next(set *Set, exclude map[string]bool) *Card {
 	pathTrie := trie.NewPathTrie()
 
 	// Create a trie based on all the different paths for the cards.
@@ -312,6 +828,12 @@ func (view *Bayesian) Next(set *Set) *Card {
 		return nil
 	})
 
+	// The trie is walked in no particular order, so the priors are sorted to make sure that they're sampled in the
+	// same order each time.
+	sort.Slice(priors, func(i, j int) bool {
+		return priors[i].Path < priors[j].Path
+	})
+
 	pathMap := map[string][]*Card{}
 	blacklisted := map[string]bool{}
 	questions := []*Card{}
@@ -330,12 +852,17 @@ func (view *Bayesian) Next(set *Set) *Card {
 			}
 
 			for _, card := range set.Cards {
-				if strings.HasPrefix(card.Path, prior.Path) && card.TotalCompletions() == 0 {
+				if strings.HasPrefix(card.Path, prior.Path) && card.TotalCompletions() == 0 && !exclude[card.ID] {
 					pathMap[prior.Path] = append(pathMap[prior.Path], card)
 				}
 			}
 		}
 
+		// Every path has been tried and none of them have any cards left.
+		if smallestPath == "" {
+			return nil
+		}
+
 		questions = pathMap[smallestPath]
 
 		if len(questions) == 0 {
@@ -349,6 +876,126 @@ func (view *Bayesian) Next(set *Set) *Card {
 // NewViewBayesian returns a new view based on Bayesian inference.
 func NewViewBayesian(seed int64) *Bayesian {
 	return &Bayesian{
-		rng: rand.New(rand.NewSource(seed)),
+		rng: newRand(seed),
+	}
+}
+
+// WithSeed returns a new Bayesian view with the given seed.
+func (view *Bayesian) WithSeed(seed int64) View {
+	return NewViewBayesian(seed)
+}
+
+// Spaced schedules cards that have already been answered using a variant of the SM-2 spaced repetition algorithm.
+// Each completion is treated as a review: a "perfect" completion is a good response, a "minor" completion is a
+// hesitant but passing response and a "major" completion is a failure, which resets the card back to the start.
+// Only cards whose next review is due are served, the most overdue first. Cards that have never been answered aren't
+// scheduled at all; the Unseen view is for those.
+// For more information on SM-2, see https://www.supermemo.com/en/archives1990-2015/english/ol/sm2.
+type Spaced struct {
+	// initialEase is the ease factor a card starts out with. The interval between reviews is multiplied by this
+	// each time a card is answered correctly.
+	initialEase float64
+
+	// minimumEase is the lowest the ease factor can fall, no matter how many mistakes are made.
+	minimumEase float64
+
+	// firstInterval and secondInterval are the intervals used after the first and second consecutive correct answer.
+	firstInterval  time.Duration
+	secondInterval time.Duration
+}
+
+// NewViewSpaced returns a new Spaced view using the standard SM-2 parameters.
+func NewViewSpaced() *Spaced {
+	return &Spaced{
+		initialEase:    2.5,
+		minimumEase:    1.3,
+		firstInterval:  24 * time.Hour,
+		secondInterval: 6 * 24 * time.Hour,
+	}
+}
+
+// spacedQualities maps completion types to SM-2 response qualities, on a scale from 0 to 5.
+var spacedQualities = map[string]float64{
+	"perfect": 5,
+	"minor":   3,
+	"major":   1,
+}
+
+// Due returns the time at which a card should next be reviewed by replaying its completion history.
+// If the card has never been completed, it returns false.
+func (view *Spaced) Due(card *Card) (time.Time, bool) {
+	history := card.History()
+	if len(history) == 0 {
+		return time.Time{}, false
+	}
+
+	ease := view.initialEase
+	repetitions := 0
+	interval := time.Duration(0)
+
+	for _, completion := range history {
+		quality := spacedQualities[completion.Type]
+
+		if quality < 3 {
+			repetitions = 0
+			interval = view.firstInterval
+		} else {
+			repetitions++
+
+			switch repetitions {
+			case 1:
+				interval = view.firstInterval
+			case 2:
+				interval = view.secondInterval
+			default:
+				interval = time.Duration(float64(interval) * ease)
+			}
+		}
+
+		ease += 0.1 - (5-quality)*(0.08+(5-quality)*0.02)
+		if ease < view.minimumEase {
+			ease = view.minimumEase
+		}
+	}
+
+	return history[len(history)-1].Date.Add(interval), true
+}
+
+// Next looks at all previous cards and decides what card to show next.
+func (view *Spaced) Next(set *Set) *Card {
+	return view.NextWithContext(set, ViewContext{Now: time.Now()})
+}
+
+// NextWithContext is like Next, but uses the time given in the context to decide which cards are due. Cards that have
+// been served recently are avoided unless there aren't any others due.
+func (view *Spaced) NextWithContext(set *Set, ctx ViewContext) *Card {
+	if card := view.next(set, ctx.Now, recentSet(ctx)); card != nil {
+		return card
 	}
+
+	return view.next(set, ctx.Now, nil)
+}
+
+// next picks the card that has been due for longest at the time given, never picking any of the cards in exclude.
+func (view *Spaced) next(set *Set, now time.Time, exclude map[string]bool) *Card {
+	var next *Card
+	var nextDue time.Time
+
+	for _, card := range set.Cards {
+		if exclude[card.ID] {
+			continue
+		}
+
+		due, scheduled := view.Due(card)
+		if !scheduled || due.After(now) {
+			continue
+		}
+
+		if next == nil || due.Before(nextDue) {
+			next = card
+			nextDue = due
+		}
+	}
+
+	return next
 }
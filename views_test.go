@@ -0,0 +1,352 @@
+package sergeant
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSpacedDue tests that the Spaced view schedules cards according to their completion history.
+func TestSpacedDue(t *testing.T) {
+	start := time.Date(2021, 02, 16, 10, 18, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	testCases := []struct {
+		name string
+		card *Card
+		due  time.Time
+	}{
+		{
+			name: "SinglePerfect",
+			card: &Card{
+				CompletionsPerfect: []Completion{{Date: start}},
+			},
+			due: start.Add(day),
+		},
+		{
+			name: "TwoPerfect",
+			card: &Card{
+				CompletionsPerfect: []Completion{{Date: start}, {Date: start.Add(day)}},
+			},
+			due: start.Add(day + 6*day),
+		},
+		{
+			name: "ThreePerfect",
+			card: &Card{
+				CompletionsPerfect: []Completion{{Date: start}, {Date: start.Add(day)}, {Date: start.Add(7 * day)}},
+			},
+			// The ease increases by 0.1 after each perfect completion, so by the third one it's 2.7.
+			due: start.Add(7*day + time.Duration(float64(6*day)*2.7)),
+		},
+		{
+			name: "MajorResets",
+			card: &Card{
+				CompletionsPerfect: []Completion{{Date: start}, {Date: start.Add(day)}},
+				CompletionsMajor:   []Completion{{Date: start.Add(7 * day)}},
+			},
+			due: start.Add(8 * day),
+		},
+	}
+
+	view := NewViewSpaced()
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			due, scheduled := view.Due(tc.card)
+
+			assert.True(t, scheduled, "expected card to be scheduled")
+			assert.Equal(t, tc.due, due, "expected different due date")
+		})
+	}
+}
+
+// TestSpacedNext tests that the Spaced view only serves cards that are due, the most overdue first.
+func TestSpacedNext(t *testing.T) {
+	now := time.Now()
+	day := 24 * time.Hour
+
+	unseen := &Card{ID: "unseen"}
+	notDue := &Card{ID: "not-due", CompletionsPerfect: []Completion{{Date: now}}}
+	due := &Card{ID: "due", CompletionsPerfect: []Completion{{Date: now.Add(-2 * day)}}}
+	overdue := &Card{ID: "overdue", CompletionsMinor: []Completion{{Date: now.Add(-10 * day)}}}
+
+	view := NewViewSpaced()
+
+	assert.Equal(t, overdue, view.Next(&Set{Cards: []*Card{unseen, notDue, due, overdue}}), "expected most overdue card")
+	assert.Equal(t, due, view.Next(&Set{Cards: []*Card{unseen, notDue, due}}), "expected only due card")
+	assert.Nil(t, view.Next(&Set{Cards: []*Card{unseen, notDue}}), "expected no card when none are due")
+}
+
+// TestDifficultiesTree tests that the probability tree is nested by path component with counts for each category.
+func TestDifficultiesTree(t *testing.T) {
+	now := time.Now()
+
+	set := &Set{Cards: []*Card{
+		{ID: "a", Path: "maths/algebra/ex1/question-a", CompletionsPerfect: []Completion{{Date: now}}},
+		{ID: "b", Path: "maths/algebra/ex2/question-b", CompletionsMajor: []Completion{{Date: now}}},
+		{ID: "c", Path: "maths/calculus/ex1/question-c"},
+		{ID: "d", Path: "physics/waves/ex1/question-d"},
+	}}
+
+	tree := NewViewDifficulties(0).Tree(set)
+
+	if !assert.Len(t, tree, 2, "expected two top-level categories") {
+		return
+	}
+
+	maths := tree[0]
+	assert.Equal(t, "maths", maths.Name)
+	assert.Equal(t, 3, maths.Cards)
+	assert.Equal(t, 1, maths.Unseen)
+	assert.Equal(t, 1, maths.Perfect)
+	assert.Equal(t, 1, maths.Major)
+
+	if assert.Len(t, maths.Children, 2, "expected two subcategories of maths") {
+		assert.Equal(t, "maths/algebra", maths.Children[0].Path)
+		assert.Equal(t, "algebra", maths.Children[0].Name)
+		assert.Equal(t, 2, maths.Children[0].Cards)
+		assert.Equal(t, 0, maths.Children[0].Unseen)
+		assert.Equal(t, "calculus", maths.Children[1].Name)
+	}
+
+	assert.Equal(t, "physics", tree[1].Name)
+}
+
+// contextTestView is a ContextView that remembers the context it was last given.
+type contextTestView struct {
+	ctx ViewContext
+}
+
+func (view *contextTestView) Next(set *Set) *Card {
+	return nil
+}
+
+func (view *contextTestView) NextWithContext(set *Set, ctx ViewContext) *Card {
+	view.ctx = ctx
+	return set.Cards[0]
+}
+
+// TestRegisterView tests that registered views are available to new stores and are given context.
+func TestRegisterView(t *testing.T) {
+	RegisterView("test-context", func() View { return &contextTestView{} })
+	t.Cleanup(func() { unregisterView("test-context") })
+
+	assert.Panics(t, func() { RegisterView("test-context", func() View { return &contextTestView{} }) }, "expected panic registering view twice")
+	assert.Panics(t, func() { RegisterView("random", func() View { return &contextTestView{} }) }, "expected panic registering built-in view")
+
+	store := NewStore(nil, Config{})
+	view, ok := store.Views["test-context"].(*contextTestView)
+	if !assert.True(t, ok, "expected registered view to be added to store") {
+		return
+	}
+
+	card := &Card{ID: "card"}
+	ctx := ViewContext{Now: time.Date(2021, 02, 16, 10, 18, 0, 0, time.UTC), Recent: []string{"other"}}
+
+	assert.Equal(t, card, NextCard(view, &Set{Cards: []*Card{card}}, ctx), "expected card from context view")
+	assert.Equal(t, ctx, view.ctx, "expected view to be given context")
+
+	_, err := parseRawConfigViewDef("mine", rawConfigViewDef{Type: "test-context"})
+	assert.NoError(t, err, "expected registered view to be usable as a type in the config")
+}
+
+// TestStoreViews tests that views defined in the config replace the default views rather than being added to them.
+func TestStoreViews(t *testing.T) {
+	assert.Contains(t, NewStore(nil, Config{}).Views, "random", "expected default views without a views section")
+
+	hard, err := parseRawConfigViewDef("hard", rawConfigViewDef{Type: "difficulties"})
+	if !assert.NoError(t, err, "not expecting error parsing view") {
+		return
+	}
+
+	views := NewStore(nil, Config{Views: map[string]ConfigView{"hard": hard}}).Views
+	assert.Contains(t, views, "hard", "expected view from the config")
+	assert.NotContains(t, views, "random", "expected default views to be replaced")
+
+	assert.Empty(t, NewStore(nil, Config{Views: map[string]ConfigView{}}).Views, "expected an empty views section to remove the default views")
+}
+
+// TestInterleavedNext tests that the Interleaved view rotates between topics according to its ratios.
+func TestInterleavedNext(t *testing.T) {
+	set := &Set{Cards: []*Card{
+		{ID: "maths-1", Path: "maths/algebra/ex1/question-1"},
+		{ID: "maths-2", Path: "maths/algebra/ex1/question-2"},
+		{ID: "maths-3", Path: "maths/calculus/ex1/question-3"},
+		{ID: "physics-1", Path: "physics/waves/ex1/question-1"},
+		{ID: "physics-2", Path: "physics/waves/ex1/question-2"},
+	}}
+
+	view := NewViewInterleaved(0, map[string]int{"maths": 2})
+
+	topics := []string{}
+	for i := 0; i < 6; i++ {
+		card := view.NextWithContext(set, ViewContext{Now: time.Now(), Key: "test"})
+		if assert.NotNil(t, card, "expected a card") {
+			topics = append(topics, cardTopic(card))
+		}
+	}
+
+	assert.Equal(t, []string{"maths", "maths", "physics", "maths", "maths", "physics"}, topics, "expected topics to follow the ratios")
+
+	card := view.NextWithContext(set, ViewContext{Now: time.Now(), Key: "other"})
+	if assert.NotNil(t, card, "expected a card") {
+		assert.Equal(t, "maths", cardTopic(card), "expected rotation to be remembered separately for each key")
+	}
+
+	card = view.NextWithContext(set, ViewContext{Now: time.Now()})
+	if assert.NotNil(t, card, "expected a card") {
+		assert.Equal(t, "maths", cardTopic(card), "expected rotation to start from the first topic without a key")
+	}
+
+	onlyPhysics := &Set{Cards: set.Cards[3:]}
+	card = view.NextWithContext(onlyPhysics, ViewContext{Now: time.Now(), Recent: []string{"physics-1"}})
+	if assert.NotNil(t, card, "expected a card when there's only one topic") {
+		assert.Equal(t, "physics-2", card.ID)
+	}
+}
+
+// TestInterleavedRatioAboveRecent tests that the Interleaved view still rotates when a ratio is longer than the number
+// of cards remembered as recent, and that it serves cards which have already been answered.
+func TestInterleavedRatioAboveRecent(t *testing.T) {
+	set := &Set{}
+	for _, topic := range []string{"maths", "physics"} {
+		for i := 0; i < 20; i++ {
+			set.Cards = append(set.Cards, &Card{
+				ID:                 fmt.Sprintf("%s-%d", topic, i),
+				Path:               fmt.Sprintf("%s/ex1/question-%d", topic, i),
+				CompletionsPerfect: []Completion{{Date: time.Now()}},
+			})
+		}
+	}
+
+	view := NewViewInterleaved(0, map[string]int{"maths": 12})
+
+	for i := 0; i < 13; i++ {
+		card := view.NextWithContext(set, ViewContext{Now: time.Now(), Key: "test"})
+		if !assert.NotNil(t, card, "expected a card from a set where every card has been answered") {
+			return
+		}
+
+		if i < 12 {
+			assert.Equal(t, "maths", cardTopic(card), "expected card %d to be from maths", i)
+		} else {
+			assert.Equal(t, "physics", cardTopic(card), "expected rotation after the ratio is reached")
+		}
+	}
+}
+
+// TestInterleavedPrefersHarder tests that the Interleaved view picks cards that are got wrong more often than ones that
+// are got right.
+func TestInterleavedPrefersHarder(t *testing.T) {
+	now := time.Now()
+	hard := &Card{ID: "hard", Path: "maths/ex1/question-1"}
+	easy := &Card{ID: "easy", Path: "maths/ex1/question-2"}
+
+	for i := 0; i < 10; i++ {
+		hard.CompletionsMajor = append(hard.CompletionsMajor, Completion{Date: now})
+		easy.CompletionsPerfect = append(easy.CompletionsPerfect, Completion{Date: now})
+	}
+
+	set := &Set{Cards: []*Card{hard, easy}}
+	view := NewViewInterleaved(0, nil)
+
+	counts := map[string]int{}
+	for i := 0; i < 100; i++ {
+		counts[view.NextWithContext(set, ViewContext{Now: now}).ID]++
+	}
+
+	assert.Greater(t, counts["hard"], counts["easy"], "expected the harder card to be picked more often")
+}
+
+// seededTestSet returns a set of cards spread across several categories, some of which have been answered.
+func seededTestSet() *Set {
+	start := time.Date(2021, 02, 16, 10, 18, 0, 0, time.UTC)
+	set := &Set{}
+
+	for i, path := range []string{"maths/algebra", "maths/calculus", "physics/waves", "physics/forces"} {
+		for j := 0; j < 5; j++ {
+			card := &Card{
+				ID:   fmt.Sprintf("%s/%d", path, j),
+				Path: fmt.Sprintf("%s/ex1/question-%d", path, j),
+			}
+
+			// Answer some cards in each category, more of them wrong in later categories.
+			if j < 2 {
+				if i%2 == 0 {
+					card.CompletionsPerfect = []Completion{{Date: start}}
+				} else {
+					card.CompletionsMajor = []Completion{{Date: start}}
+				}
+			}
+
+			set.Cards = append(set.Cards, card)
+		}
+	}
+
+	return set
+}
+
+// TestSeededViews tests that views given the same seed pick the same cards.
+func TestSeededViews(t *testing.T) {
+	set := seededTestSet()
+
+	views := map[string]View{
+		"Random":       NewViewRandom(0),
+		"Unseen":       NewViewUnseen(0),
+		"Difficulties": NewViewDifficulties(0),
+		"Bayesian":     NewViewBayesian(0),
+		"Interleaved":  NewViewInterleaved(0, nil),
+	}
+
+	for name, view := range views {
+		t.Run(name, func(t *testing.T) {
+			first, second := SeedView(view, 42), SeedView(view, 42)
+
+			for i := 0; i < 20; i++ {
+				a, b := first.Next(set), second.Next(set)
+				if !assert.NotNil(t, a, "expected a card") {
+					return
+				}
+
+				assert.Equal(t, a.ID, b.ID, "expected the same card from views with the same seed")
+			}
+		})
+	}
+
+	tuned := NewViewDifficulties(0)
+	tuned.power = 4
+
+	assert.Equal(t, 4.0, SeedView(tuned, 1).(*Difficulties).power, "expected seeded view to keep its parameters")
+
+	spaced := NewViewSpaced()
+	assert.Same(t, spaced, SeedView(spaced, 1), "expected views without randomness to be returned unchanged")
+}
+
+// TestViewsConcurrent tests that the default views can be used from several goroutines at once. It's most useful when
+// run with the race detector.
+func TestViewsConcurrent(t *testing.T) {
+	set := seededTestSet()
+
+	for name, view := range DefaultViews {
+		t.Run(name, func(t *testing.T) {
+			var wg sync.WaitGroup
+
+			for i := 0; i < 8; i++ {
+				wg.Add(1)
+
+				go func() {
+					defer wg.Done()
+
+					for j := 0; j < 10; j++ {
+						view.Next(set)
+					}
+				}()
+			}
+
+			wg.Wait()
+		})
+	}
+}